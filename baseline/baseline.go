@@ -0,0 +1,119 @@
+// Package baseline reads and writes baseline files, which record keys of
+// pre-existing violations so that they are not reported.
+package baseline
+
+import (
+	"checkstyle-review/checkstylexml"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// FileName is the default name of the baseline file in the root of the
+// repository.
+const FileName = ".checkstyle-baseline.json"
+
+// version is the version of the baseline file format.
+const version = 1
+
+// Baseline represents a baseline file.
+type Baseline struct {
+	Version    int      `json:"version"`
+	Violations []*Entry `json:"violations"`
+}
+
+// Entry represents a violation of the baseline. Only Key is used for
+// matching; the other fields make the file reviewable.
+type Entry struct {
+	// Key is fingerprint.BaselineKey of the violation.
+	Key     string `json:"key"`
+	Path    string `json:"path"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewEntry creates an entry of a violation at the path relative to the root
+// of the repository.
+func NewEntry(path string, e *checkstylexml.CheckStyleErrorFormat) *Entry {
+	return &Entry{
+		Key:     e.BaselineKey,
+		Path:    path,
+		Source:  e.Source,
+		Message: e.Message,
+	}
+}
+
+// Load loads a baseline file.
+func Load(path string) (*Baseline, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bl Baseline
+	if err := json.Unmarshal(b, &bl); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	if bl.Version != version {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", bl.Version, path)
+	}
+	return &bl, nil
+}
+
+// Write writes the baseline file sorted by path and key, so that
+// regenerating it produces small diffs.
+func (bl *Baseline) Write(path string) error {
+	bl.Version = version
+	sort.Slice(bl.Violations, func(i, j int) bool {
+		a, b := bl.Violations[i], bl.Violations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Key < b.Key
+	})
+	b, err := json.MarshalIndent(bl, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Keys returns the set of keys of the baseline.
+func (bl *Baseline) Keys() map[string]bool {
+	keys := make(map[string]bool, len(bl.Violations))
+	for _, e := range bl.Violations {
+		keys[e.Key] = true
+	}
+	return keys
+}
+
+// Merge adds entries whose key is not in the baseline yet. With prune,
+// entries whose key is not in entries are removed first. It
+// returns the numbers of added and pruned entries.
+func (bl *Baseline) Merge(entries []*Entry, prune bool) (added, pruned int) {
+	current := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		current[e.Key] = true
+	}
+	kept := make([]*Entry, 0, len(bl.Violations)+len(entries))
+	seen := make(map[string]bool, len(bl.Violations))
+	for _, e := range bl.Violations {
+		if prune && !current[e.Key] {
+			pruned++
+			continue
+		}
+		if !seen[e.Key] {
+			seen[e.Key] = true
+			kept = append(kept, e)
+		}
+	}
+	for _, e := range entries {
+		if !seen[e.Key] {
+			seen[e.Key] = true
+			kept = append(kept, e)
+			added++
+		}
+	}
+	bl.Violations = kept
+	return added, pruned
+}
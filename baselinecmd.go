@@ -0,0 +1,63 @@
+package main
+
+import (
+	"checkstyle-review/baseline"
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/pathutil"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeBaseline runs the baseline subcommand, which adds the keys of all
+// violations of the reports to the baseline file. The file is meant to be
+// committed, so that later runs do not report these violations.
+//
+//	checkstyle-review baseline -xmlPath '**/build/reports/checkstyle/*.xml' -prune
+func writeBaseline(errorMap map[string][]*checkstylexml.CheckStyleErrorFormat, rootPath string) error {
+	path := baselinePath(rootPath)
+	bl, err := baseline.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		bl, err = &baseline.Baseline{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []*baseline.Entry
+	for fileName, results := range errorMap {
+		relPath := pathutil.NormalizePath(fileName, rootPath, "")
+		for _, res := range results {
+			entries = append(entries, baseline.NewEntry(relPath, res))
+		}
+	}
+	added, pruned := bl.Merge(entries, opt.prune)
+	if err := bl.Write(path); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Baseline %s: %d violations, %d added, %d pruned\n", path, len(bl.Violations), added, pruned)
+	return nil
+}
+
+// loadBaseline loads keys of the baseline file. It returns nil if the
+// default baseline file does not exist.
+func loadBaseline(rootPath string) (map[string]bool, error) {
+	path := baselinePath(rootPath)
+	bl, err := baseline.Load(path)
+	if errors.Is(err, fs.ErrNotExist) && opt.baseline == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Using baseline %s: %d violations\n", path, len(bl.Violations))
+	return bl.Keys(), nil
+}
+
+func baselinePath(rootPath string) string {
+	if opt.baseline != "" {
+		return opt.baseline
+	}
+	return filepath.Join(rootPath, baseline.FileName)
+}
@@ -2,10 +2,15 @@ package checkstylexml
 
 import (
 	"encoding/xml"
-	"github.com/google/uuid"
 	"io"
 )
 
+// Parser parses a static analysis report into violations.
+type Parser interface {
+	ParseErrors(r io.Reader) ([]*CheckStyleErrorFormat, error)
+}
+
+// CheckStyleXML is a parser of checkstyle XML report.
 type CheckStyleXML struct{}
 
 func (*CheckStyleXML) Parse(r io.Reader) (*CheckStyleResult, error) {
@@ -17,6 +22,28 @@ func (*CheckStyleXML) Parse(r io.Reader) (*CheckStyleResult, error) {
 	return result, nil
 }
 
+// ParseErrors parses checkstyle XML report and returns its violations.
+func (c *CheckStyleXML) ParseErrors(r io.Reader) ([]*CheckStyleErrorFormat, error) {
+	result, err := c.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	var errs []*CheckStyleErrorFormat
+	for _, file := range result.Files {
+		for _, e := range file.Errors {
+			errs = append(errs, &CheckStyleErrorFormat{
+				File:     file.Name,
+				Column:   e.Column,
+				Line:     e.Line,
+				Message:  e.Message,
+				Severity: e.Severity,
+				Source:   e.Source,
+			})
+		}
+	}
+	return errs, nil
+}
+
 // CheckStyleResult represents checkstyle XML result.
 // <?xml version="1.0" encoding="utf-8"?><checkstyle version="4.3"><file ...></file>...</checkstyle>
 //
@@ -44,12 +71,22 @@ type CheckStyleError struct {
 	Source   string `xml:"source,attr,omitempty"`
 }
 
+// CheckStyleErrorFormat represents a single violation of a file.
 type CheckStyleErrorFormat struct {
-	ErrKey   uuid.UUID
-	File     string
-	Column   int    `xml:"column,attr,omitempty"`
-	Line     int    `xml:"line,attr"`
-	Message  string `xml:"message,attr"`
-	Severity string `xml:"severity,attr,omitempty"`
-	Source   string `xml:"source,attr,omitempty"`
+	// ErrKey is the content based fingerprint of the violation.
+	// See the fingerprint package.
+	ErrKey string
+	// BaselineKey identifies the violation in a baseline file.
+	BaselineKey string
+	File        string
+	Column      int    `xml:"column,attr,omitempty"`
+	Line        int    `xml:"line,attr"`
+	Message     string `xml:"message,attr"`
+	Severity    string `xml:"severity,attr,omitempty"`
+	Source      string `xml:"source,attr,omitempty"`
+
+	// EndLine and EndColumn are optional end position of the violation.
+	// Checkstyle itself only reports a start position.
+	EndLine   int
+	EndColumn int
 }
@@ -3,7 +3,6 @@ package comment
 import (
 	"checkstyle-review/checkstylexml"
 	"fmt"
-	"github.com/google/uuid"
 	"strings"
 )
 
@@ -11,24 +10,66 @@ import (
 type Comment struct {
 	Result   *checkstylexml.CheckStyleErrorFormat
 	ToolName string
+
+	// OutsideDiff is true if the violation is reported but is not on a line
+	// of the diff, so it cannot be posted as an inline comment.
+	OutsideDiff bool
+
+	// EndLine is the last line an inline comment spans. It is the end line
+	// of the violation clamped to the hunk of its start line, since GitHub
+	// rejects ranges across hunks. 0 comments on the start line only.
+	EndLine int
 }
 
-type PostedComments map[uuid.UUID]struct{}
+// PostedComments represents posted comments keyed by path and line.
+type PostedComments map[string]map[int][]string
 
 // IsPosted returns true if a given comment has been posted in code review service already,
 // otherwise returns false. It sees comments with same path, same position,
 // and same body as same comments.
-func (p PostedComments) IsPosted(key uuid.UUID) bool {
-	if _, ok := p[key]; !ok {
+func (p PostedComments) IsPosted(path string, lineNum int, body string) bool {
+	if _, ok := p[path]; !ok {
 		return false
 	}
-	return true
+	bodies, ok := p[path][lineNum]
+	if !ok {
+		return false
+	}
+	for _, b := range bodies {
+		// Posted bodies may carry trailing content such as code snippet links,
+		// so only the leading paragraph has to match.
+		if b == body || strings.HasPrefix(b, body+"\n\n") {
+			return true
+		}
+	}
+	return false
 }
 
-func (p PostedComments) AddPostedComment(key uuid.UUID) {
-	if _, ok := p[key]; !ok {
-		p[key] = struct{}{}
+// AddPostedComment adds a posted comment.
+func (p PostedComments) AddPostedComment(path string, lineNum int, body string) {
+	if _, ok := p[path]; !ok {
+		p[path] = make(map[int][]string)
 	}
+	if _, ok := p[path][lineNum]; !ok {
+		p[path][lineNum] = make([]string, 0)
+	}
+	p[path][lineNum] = append(p[path][lineNum], body)
+}
+
+// PostedFingerprints represents fingerprints of violations which have been
+// posted in code review service already.
+type PostedFingerprints map[string]struct{}
+
+// IsPosted returns true if a violation with a given fingerprint has been
+// posted already, otherwise returns false.
+func (p PostedFingerprints) IsPosted(fp string) bool {
+	_, ok := p[fp]
+	return ok
+}
+
+// AddPostedFingerprint adds a posted fingerprint.
+func (p PostedFingerprints) AddPostedFingerprint(fp string) {
+	p[fp] = struct{}{}
 }
 
 // MarkdownComment creates comment body markdown.
@@ -46,16 +87,64 @@ func MarkdownComment(c *Comment) string {
 }
 
 func parseSeverity(c *Comment) string {
-	s := c.Result.Severity
+	switch ParseSeverity(c.Result.Severity) {
+	case SeverityError:
+		return "🚫"
+	case SeverityWarning:
+		return "⚠️"
+	case SeverityInfo:
+		return "📝"
+	default:
+		return ""
+	}
+}
+
+// Severity represents a normalized severity of a violation.
+type Severity int
+
+const (
+	// SeverityUnknown represents a missing or unrecognized severity
+	SeverityUnknown Severity = iota
+	// SeverityInfo represents info and note
+	SeverityInfo
+	// SeverityWarning represents warning
+	SeverityWarning
+	// SeverityError represents error
+	SeverityError
+)
+
+// ParseSeverity normalizes a severity reported by a tool.
+func ParseSeverity(s string) Severity {
 	switch s {
 	case "error", "ERROR", "Error", "e", "E":
-		return "🚫"
+		return SeverityError
 	case "warning", "WARNING", "Warning", "w", "W":
-		return "⚠️"
+		return SeverityWarning
 	case "info", "INFO", "Info", "i", "I",
 		"note", "NOTE", "Note", "n", "N": // Treat note as info.
-		return "📝"
+		return SeverityInfo
 	default:
-		return ""
+		return SeverityUnknown
+	}
+}
+
+// ParseLevel parses a severity threshold: none, info, warning or error.
+// none is returned as SeverityUnknown, which means no threshold.
+func ParseLevel(s string) (Severity, error) {
+	if s == "none" || s == "" {
+		return SeverityUnknown, nil
+	}
+	if level := ParseSeverity(s); level != SeverityUnknown {
+		return level, nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity level: %q", s)
+}
+
+// AtLeast returns true if the severity is at or above a given threshold.
+// Unknown severities are treated as errors so that they are never hidden.
+func (s Severity) AtLeast(level Severity) bool {
+	if s == SeverityUnknown {
+		return true
 	}
+	return s >= level
 }
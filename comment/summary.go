@@ -0,0 +1,175 @@
+package comment
+
+import (
+	"checkstyle-review/checkstylexml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Summary represents statistics of the violations of a run.
+type Summary struct {
+	// InDiff are violations on lines of the diff.
+	InDiff []*checkstylexml.CheckStyleErrorFormat
+	// OutOfDiff are all the other violations.
+	OutOfDiff []*checkstylexml.CheckStyleErrorFormat
+	// Fixed are violations of the base branch which are not found anymore.
+	Fixed []*checkstylexml.CheckStyleErrorFormat
+	// Suppressed counts violations dropped by each suppression rule.
+	Suppressed map[string]int
+	// RunURL is an optional link to the CI run.
+	RunURL string
+}
+
+// summaryRow counts violations in and out of the diff.
+type summaryRow struct {
+	name      string
+	inDiff    int
+	outOfDiff int
+}
+
+func (r *summaryRow) total() int {
+	return r.inDiff + r.outOfDiff
+}
+
+// Markdown creates the summary markdown with tables of violation counts per
+// severity and per rule.
+func (s *Summary) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("### Checkstyle summary\n\n")
+	sb.WriteString(fmt.Sprintf("**%d** violations in the diff, **%d** outside the diff.\n\n", len(s.InDiff), len(s.OutOfDiff)))
+
+	bySeverity := s.count(func(e *checkstylexml.CheckStyleErrorFormat) string {
+		return severityName(ParseSeverity(e.Severity))
+	})
+	writeSummaryTable(&sb, "Severity", bySeverity)
+
+	bySource := s.count(func(e *checkstylexml.CheckStyleErrorFormat) string {
+		if e.Source == "" {
+			return "(unknown)"
+		}
+		return e.Source
+	})
+	writeSummaryTable(&sb, "Rule", bySource)
+
+	s.writeSuppressedTable(&sb)
+	s.writeFixed(&sb)
+
+	if s.RunURL != "" {
+		sb.WriteString(fmt.Sprintf("[View the run](%s)\n", s.RunURL))
+	}
+	return sb.String()
+}
+
+// count groups violations by key and returns rows sorted by total count.
+func (s *Summary) count(key func(*checkstylexml.CheckStyleErrorFormat) string) []*summaryRow {
+	rows := make(map[string]*summaryRow)
+	row := func(e *checkstylexml.CheckStyleErrorFormat) *summaryRow {
+		k := key(e)
+		if _, ok := rows[k]; !ok {
+			rows[k] = &summaryRow{name: k}
+		}
+		return rows[k]
+	}
+	for _, e := range s.InDiff {
+		row(e).inDiff++
+	}
+	for _, e := range s.OutOfDiff {
+		row(e).outOfDiff++
+	}
+	sorted := make([]*summaryRow, 0, len(rows))
+	for _, r := range rows {
+		sorted = append(sorted, r)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].total() != sorted[j].total() {
+			return sorted[i].total() > sorted[j].total()
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+func writeSummaryTable(sb *strings.Builder, title string, rows []*summaryRow) {
+	if len(rows) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("| %s | In diff | Outside diff | Total |\n", title))
+	sb.WriteString("| --- | ---: | ---: | ---: |\n")
+	for _, r := range rows {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", escapeTableCell(r.name), r.inDiff, r.outOfDiff, r.total()))
+	}
+	sb.WriteString("\n")
+}
+
+// writeSuppressedTable writes the counts of suppressed violations sorted by
+// count.
+func (s *Summary) writeSuppressedTable(sb *strings.Builder) {
+	if len(s.Suppressed) == 0 {
+		return
+	}
+	names := make([]string, 0, len(s.Suppressed))
+	for name := range s.Suppressed {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Suppressed[names[i]] != s.Suppressed[names[j]] {
+			return s.Suppressed[names[i]] > s.Suppressed[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	sb.WriteString("| Suppression | Suppressed |\n")
+	sb.WriteString("| --- | ---: |\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", escapeTableCell(name), s.Suppressed[name]))
+	}
+	sb.WriteString("\n")
+}
+
+// maxFixedListed is the maximum number of fixed violations listed.
+const maxFixedListed = 50
+
+// writeFixed writes the list of fixed violations sorted by file and line.
+func (s *Summary) writeFixed(sb *strings.Builder) {
+	if len(s.Fixed) == 0 {
+		return
+	}
+	fixed := make([]*checkstylexml.CheckStyleErrorFormat, len(s.Fixed))
+	copy(fixed, s.Fixed)
+	sort.SliceStable(fixed, func(i, j int) bool {
+		if fixed[i].File != fixed[j].File {
+			return fixed[i].File < fixed[j].File
+		}
+		return fixed[i].Line < fixed[j].Line
+	})
+	sb.WriteString(fmt.Sprintf("<details>\n<summary>✅ %d violations fixed</summary>\n\n", len(fixed)))
+	for i, e := range fixed {
+		if i == maxFixedListed {
+			sb.WriteString(fmt.Sprintf("- ... and %d more\n", len(fixed)-maxFixedListed))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- `%s:%d` %s", e.File, e.Line, e.Message))
+		if e.Source != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", e.Source))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n</details>\n\n")
+}
+
+func severityName(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "🚫 error"
+	case SeverityWarning:
+		return "⚠️ warning"
+	case SeverityInfo:
+		return "📝 info"
+	default:
+		return "unknown"
+	}
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
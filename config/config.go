@@ -0,0 +1,148 @@
+// Package config loads the repository configuration file.
+//
+// The file is looked up from the working directory up to the root of the
+// repository. JSON is accepted as well, since it is a subset of YAML. Paths
+// in the file are relative to the root of the repository.
+//
+//	# .checkstyle-review.yml
+//	reports:
+//	  - path: "**/build/reports/checkstyle/*.xml"
+//	  - path: build/reports/pmd/main.xml
+//	    format: pmd
+//	base-reports:
+//	  - path: "build/base-reports/*.xml"
+//	filter-mode: added
+//	min-severity: warning
+//	fail-level: error
+//	tool-name: Checkstyle
+//	ignore-rules:
+//	  - com.puppycrawl.tools.checkstyle.checks.javadoc.JavadocMethodCheck
+//	paths:
+//	  include: ["src/main/**"]
+//	  exclude: ["**/generated/**"]
+//	suppressions:
+//	  - name: javadoc in tests
+//	    source-pattern: "\\.JavadocMethodCheck$"
+//	    paths: ["**/src/test/**"]
+//	baseline: config/checkstyle-baseline.json
+//	comments:
+//	  max: 100
+//	  overflow: reviews
+//	  review-delay: 10s
+package config
+
+import (
+	"bytes"
+	"checkstyle-review/github/util"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of the configuration file in order of precedence.
+var FileNames = []string{
+	".checkstyle-review.yml",
+	".checkstyle-review.yaml",
+	".checkstyle-review.json",
+}
+
+// Config represents the repository configuration. Zero values mean that the
+// default or the command line flag is used.
+type Config struct {
+	Reports []Report `yaml:"reports"`
+	// BaseReports are reports of the base branch. Only violations which are
+	// not in them are reported.
+	BaseReports []Report `yaml:"base-reports"`
+	FilterMode  string   `yaml:"filter-mode"`
+	MinSeverity string   `yaml:"min-severity"`
+	FailLevel   string   `yaml:"fail-level"`
+	// ToolName is the name of the tool shown in comments.
+	ToolName string `yaml:"tool-name"`
+	// IgnoreRules are sources of violations which are never reported.
+	IgnoreRules  []string      `yaml:"ignore-rules"`
+	Paths        Paths         `yaml:"paths"`
+	Comments     Comments      `yaml:"comments"`
+	Suppressions []Suppression `yaml:"suppressions"`
+	// Baseline is the path of the baseline file.
+	Baseline string `yaml:"baseline"`
+}
+
+// Report represents a report path or glob pattern with its format.
+type Report struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+}
+
+// Paths represents glob patterns of paths whose violations are reported.
+// Paths are relative to the root of the repository. Exclude wins over Include
+// and an empty Include includes every path.
+type Paths struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// Suppression represents a rule which hides matching violations. Every
+// condition which is set must match. Patterns are regular expressions.
+type Suppression struct {
+	Name           string   `yaml:"name"`
+	Source         string   `yaml:"source"`
+	SourcePattern  string   `yaml:"source-pattern"`
+	MessagePattern string   `yaml:"message-pattern"`
+	Paths          []string `yaml:"paths"`
+}
+
+// Comments represents limits of posted comments.
+type Comments struct {
+	Max         int           `yaml:"max"`
+	Overflow    string        `yaml:"overflow"`
+	ReviewDelay time.Duration `yaml:"review-delay"`
+}
+
+// Find returns the path of the configuration file. It walks up from the
+// working directory to the root of the repository and returns an empty string
+// if there is no configuration file.
+func Find() (string, error) {
+	root, err := util.GetGitRoot()
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if !strings.HasPrefix(dir, root) || dir == root || parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load loads a configuration file.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	// Misspelled keys would be silently ignored otherwise.
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &c, nil
+}
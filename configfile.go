@@ -0,0 +1,138 @@
+package main
+
+import (
+	"checkstyle-review/config"
+	"checkstyle-review/github/util"
+	"checkstyle-review/runner"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// loadConfig applies the repository configuration file to options. Flags set
+// on the command line override the configuration.
+func loadConfig() error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	cfg := &config.Config{}
+	path, err := config.Find()
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Using config: %s\n", path)
+		if cfg, err = config.Load(path); err != nil {
+			return err
+		}
+	}
+
+	// Paths of the configuration file are relative to the root of the
+	// repository, so that it works from any subdirectory.
+	root, err := util.GetGitRoot()
+	if err != nil {
+		return err
+	}
+	opt.reports = reportSpecs(opt.paths, cfg.Reports, root, set["xmlPath"], set["format"])
+	opt.baseReports = reportSpecs(opt.basePaths, cfg.BaseReports, root, set["base-xmlPath"], set["format"])
+	for _, report := range opt.baseReports {
+		if report.path == stdinPath {
+			return errors.New("base reports cannot be read from stdin")
+		}
+	}
+	if !set["filter-mode"] && cfg.FilterMode != "" {
+		opt.filterMode = cfg.FilterMode
+	}
+	if !set["min-severity"] && cfg.MinSeverity != "" {
+		opt.minSeverity = cfg.MinSeverity
+	}
+	if !set["fail-level"] && cfg.FailLevel != "" {
+		opt.failLevel = cfg.FailLevel
+	}
+	if !set["max-comments"] && cfg.Comments.Max != 0 {
+		opt.maxComments = cfg.Comments.Max
+	}
+	if !set["overflow"] && cfg.Comments.Overflow != "" {
+		opt.overflow = cfg.Comments.Overflow
+	}
+	if !set["baseline"] && cfg.Baseline != "" {
+		opt.baseline = rootRelative(root, cfg.Baseline)
+	}
+	if !set["review-delay"] && cfg.Comments.ReviewDelay != 0 {
+		opt.reviewDelay = cfg.Comments.ReviewDelay
+	}
+
+	opt.toolName = cfg.ToolName
+	if opt.toolName == "" {
+		opt.toolName = runner.DefaultToolName
+	}
+	opt.ignoreRules = cfg.IgnoreRules
+	opt.includePaths = cfg.Paths.Include
+	opt.excludePaths = cfg.Paths.Exclude
+	opt.suppressions, err = suppressions(cfg.Suppressions)
+	return err
+}
+
+// reportSpecs returns report paths given by flags, or by the configuration
+// file relative to root if no path flag is set. The -format flag applies to
+// reports of the configuration file without a format, or to all of them if it
+// is set.
+func reportSpecs(paths []string, reports []config.Report, root string, pathsSet, formatSet bool) []reportSpec {
+	var specs []reportSpec
+	if pathsSet {
+		for _, path := range paths {
+			specs = append(specs, reportSpec{path: path, format: opt.format})
+		}
+		return specs
+	}
+	for _, report := range reports {
+		format := report.Format
+		if format == "" || formatSet {
+			format = opt.format
+		}
+		path := report.Path
+		if path != stdinPath {
+			path = rootRelative(root, path)
+		}
+		specs = append(specs, reportSpec{path: path, format: format})
+	}
+	return specs
+}
+
+// rootRelative resolves a path of the configuration file against the root of
+// the repository.
+func rootRelative(root, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// suppressions compiles suppressions of the configuration file.
+func suppressions(cfgs []config.Suppression) ([]*runner.Suppression, error) {
+	ss := make([]*runner.Suppression, 0, len(cfgs))
+	for i, c := range cfgs {
+		s := &runner.Suppression{Name: c.Name, Source: c.Source, Paths: c.Paths}
+		var err error
+		if c.SourcePattern != "" {
+			if s.SourcePattern, err = regexp.Compile(c.SourcePattern); err != nil {
+				return nil, fmt.Errorf("invalid source-pattern of suppression %d: %w", i+1, err)
+			}
+		}
+		if c.MessagePattern != "" {
+			if s.MessagePattern, err = regexp.Compile(c.MessagePattern); err != nil {
+				return nil, fmt.Errorf("invalid message-pattern of suppression %d: %w", i+1, err)
+			}
+		}
+		if c.Source == "" && c.SourcePattern == "" && c.MessagePattern == "" && len(c.Paths) == 0 {
+			return nil, fmt.Errorf("suppression %d has no condition", i+1)
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
@@ -62,6 +62,26 @@ type GitHubPullRequest struct {
 	} `json:"base"`
 }
 
+// GitHubRunURL returns the URL of the current GitHub Actions run, or an empty
+// string outside GitHub Actions.
+func GitHubRunURL() string {
+	server, repo, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || runID == "" {
+		return ""
+	}
+	return server + "/" + repo + "/actions/runs/" + runID
+}
+
+// GitHubBlobBaseURL returns the base URL of files at a given commit, or an
+// empty string outside GitHub Actions.
+func GitHubBlobBaseURL(sha string) string {
+	server, repo := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY")
+	if server == "" || repo == "" || sha == "" {
+		return ""
+	}
+	return server + "/" + repo + "/blob/" + sha
+}
+
 func loadGitHubEventFromPath(eventPath string) (*GitHubEvent, error) {
 	f, err := os.Open(eventPath)
 	if err != nil {
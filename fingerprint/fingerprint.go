@@ -0,0 +1,189 @@
+// Package fingerprint computes content based identifiers of violations.
+//
+// A fingerprint does not depend on the line number of a violation, so the same
+// violation keeps the same fingerprint across runs even if lines above it are
+// added or removed. Identical violations of a file are numbered by occurrence,
+// like partialFingerprints of SARIF, so that fingerprints are unique.
+package fingerprint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// contextLines is the number of lines before and after the violation line
+// which are part of the fingerprint.
+const contextLines = 1
+
+const (
+	markerPrefix     = "checkstyle-review:fingerprint:"
+	ruleMarkerPrefix = "checkstyle-review:rule:"
+)
+
+var (
+	markerRe     = regexp.MustCompile(`<!-- ` + markerPrefix + `([0-9a-f]+) -->`)
+	ruleMarkerRe = regexp.MustCompile(`<!-- ` + ruleMarkerPrefix + `([0-9a-f]+) -->`)
+)
+
+// Compute returns the fingerprint of a violation.
+//
+// path should be relative to the root of the repository. lines are the lines
+// of the file the violation belongs to and may be nil if the file cannot be
+// read. occurrence numbers violations of the file with the same path, source,
+// message and line content, starting from 0.
+func Compute(path, source, message string, lines []string, line, occurrence int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00", normalizePath(path), source, normalizeText(message), occurrence)
+	for i := line - contextLines; i <= line+contextLines; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00", normalizeText(lines[i-1]))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// BaselineKey returns the key of a violation in a baseline. Unlike a
+// fingerprint it only depends on the content of the violation line, so editing
+// lines around a pre-existing violation does not bring it back.
+func BaselineKey(path, source, message, text string, occurrence int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "baseline\x00%s\x00%s\x00%s\x00%s\x00%d", normalizePath(path), source, normalizeText(message), normalizeText(text), occurrence)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// RuleKey returns a key of violations of the same rule and message in a file.
+// Unlike a fingerprint it does not change when lines around a violation are
+// edited, but it is not unique.
+func RuleKey(path, source, message string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", normalizePath(path), source, normalizeText(message))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Fingerprinter computes fingerprints of the violations of a run. It reads
+// each file once and counts occurrences of identical violations.
+type Fingerprinter struct {
+	// Log receives a message for each file which cannot be read.
+	Log io.Writer
+
+	lines       map[string][]string
+	occurrences map[string]int
+}
+
+// NewFingerprinter creates a Fingerprinter which logs to log.
+func NewFingerprinter(log io.Writer) *Fingerprinter {
+	return &Fingerprinter{
+		Log:         log,
+		lines:       make(map[string][]string),
+		occurrences: make(map[string]int),
+	}
+}
+
+// Fingerprint returns the fingerprint and the baseline key of a violation in
+// file at path, which is relative to the root of the repository. Violations of
+// a file must be given in order of their position so that occurrences are
+// stable.
+func (f *Fingerprinter) Fingerprint(file, path, source, message string, line int) (fp, baselineKey string) {
+	lines := f.readLines(file)
+	text := lineText(lines, line)
+	occurrence := f.occurrence(path, source, message, text)
+	return Compute(path, source, message, lines, line, occurrence), BaselineKey(path, source, message, text, occurrence)
+}
+
+// occurrence returns the number of violations with the same key seen before.
+func (f *Fingerprinter) occurrence(path, source, message, text string) int {
+	key := strings.Join([]string{normalizePath(path), source, normalizeText(message), text}, "\x00")
+	n := f.occurrences[key]
+	f.occurrences[key]++
+	return n
+}
+
+func (f *Fingerprinter) readLines(file string) []string {
+	lines, ok := f.lines[file]
+	if ok {
+		return lines
+	}
+	lines, err := ReadLines(file)
+	if err != nil && f.Log != nil {
+		fmt.Fprintf(f.Log, "fingerprints of %s do not include line content: %v\n", file, err)
+	}
+	f.lines[file] = lines
+	return lines
+}
+
+// lineText returns the normalized content of a line, or an empty string if
+// the line is not in lines.
+func lineText(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return normalizeText(lines[line-1])
+}
+
+// ReadLines returns lines of a given file.
+func ReadLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Marker returns an HTML comment which embeds a fingerprint invisibly in a
+// markdown comment body.
+func Marker(fp string) string {
+	return fmt.Sprintf("<!-- %s%s -->", markerPrefix, fp)
+}
+
+// Extract returns the fingerprint embedded in a comment body by Marker.
+func Extract(body string) (string, bool) {
+	m := markerRe.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// RuleMarker returns an HTML comment which embeds a rule key invisibly in a
+// markdown comment body.
+func RuleMarker(key string) string {
+	return fmt.Sprintf("<!-- %s%s -->", ruleMarkerPrefix, key)
+}
+
+// ExtractRuleKey returns the rule key embedded in a comment body by
+// RuleMarker.
+func ExtractRuleKey(body string) (string, bool) {
+	m := ruleMarkerRe.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+func normalizePath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// normalizeText collapses whitespace so that re-indentation does not change
+// the fingerprint.
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,136 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keys returns fingerprints and baseline keys of violations on lines of a
+// file with content, in order.
+func keys(t *testing.T, content string, lines ...int) (fps, baselineKeys []string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "A.java")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFingerprinter(nil)
+	for _, l := range lines {
+		fp, bk := f.Fingerprint(file, "src/A.java", "MagicNumber", "'42' is a magic number.", l)
+		fps = append(fps, fp)
+		baselineKeys = append(baselineKeys, bk)
+	}
+	return fps, baselineKeys
+}
+
+func TestFingerprint(t *testing.T) {
+	const base = "class A {\n  int x = 42;\n}\n"
+	tests := []struct {
+		name        string
+		before      string
+		beforeLine  int
+		after       string
+		afterLine   int
+		sameFP      bool
+		sameBaseKey bool
+	}{
+		{
+			name:   "unchanged",
+			before: base, beforeLine: 2,
+			after: base, afterLine: 2,
+			sameFP: true, sameBaseKey: true,
+		},
+		{
+			name:   "line number shifted",
+			before: base, beforeLine: 2,
+			after: "// header\n\n" + base, afterLine: 4,
+			sameFP: true, sameBaseKey: true,
+		},
+		{
+			name:   "re-indented",
+			before: base, beforeLine: 2,
+			after: "class A {\n\tint  x = 42;\n}\n", afterLine: 2,
+			sameFP: true, sameBaseKey: true,
+		},
+		{
+			name:   "neighbouring line changed",
+			before: base, beforeLine: 2,
+			after: "class A {\n  int x = 42;\n  int y;\n}\n", afterLine: 2,
+			sameFP: false, sameBaseKey: true,
+		},
+		{
+			name:   "violation line changed",
+			before: base, beforeLine: 2,
+			after: "class A {\n  long x = 42;\n}\n", afterLine: 2,
+			sameFP: false, sameBaseKey: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fpBefore, bkBefore := keys(t, tt.before, tt.beforeLine)
+			fpAfter, bkAfter := keys(t, tt.after, tt.afterLine)
+			if got := fpBefore[0] == fpAfter[0]; got != tt.sameFP {
+				t.Errorf("same fingerprint = %v, want %v", got, tt.sameFP)
+			}
+			if got := bkBefore[0] == bkAfter[0]; got != tt.sameBaseKey {
+				t.Errorf("same baseline key = %v, want %v", got, tt.sameBaseKey)
+			}
+		})
+	}
+}
+
+func TestFingerprintIdenticalViolations(t *testing.T) {
+	block := "  void f() {\n    int x = 42;\n  }\n"
+	content := "class A {\n" + strings.Repeat(block, 2) + "}\n"
+
+	fps, bks := keys(t, content, 3, 6)
+	if fps[0] == fps[1] {
+		t.Errorf("identical violations share fingerprint %s", fps[0])
+	}
+	if bks[0] == bks[1] {
+		t.Errorf("identical violations share baseline key %s", bks[0])
+	}
+
+	// Occurrences are numbered by position, so another run gives the same keys.
+	fps2, bks2 := keys(t, content, 3, 6)
+	for i := range fps {
+		if fps[i] != fps2[i] || bks[i] != bks2[i] {
+			t.Errorf("keys of violation %d changed between runs: %s/%s, %s/%s", i, fps[i], bks[i], fps2[i], bks2[i])
+		}
+	}
+}
+
+func TestFingerprintUnreadableFile(t *testing.T) {
+	var log strings.Builder
+	f := NewFingerprinter(&log)
+	missing := filepath.Join(t.TempDir(), "missing.java")
+	fp1, _ := f.Fingerprint(missing, "src/A.java", "S", "m", 3)
+	fp2, _ := f.Fingerprint(missing, "src/A.java", "S", "m", 9)
+	if fp1 == fp2 {
+		t.Errorf("violations of an unreadable file share fingerprint %s", fp1)
+	}
+	if !strings.Contains(log.String(), missing) {
+		t.Errorf("log = %q, want a message about %s", log.String(), missing)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	lines := []string{"a", "b", "c"}
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"same input", Compute("A.java", "S", "m", lines, 2, 0), Compute("A.java", "S", "m", lines, 2, 0), true},
+		{"path separators", Compute("src/A.java", "S", "m", lines, 2, 0), Compute("src//A.java", "S", "m", lines, 2, 0), true},
+		{"message whitespace", Compute("A.java", "S", "a  b", lines, 2, 0), Compute("A.java", "S", "a b", lines, 2, 0), true},
+		{"occurrence", Compute("A.java", "S", "m", lines, 2, 0), Compute("A.java", "S", "m", lines, 2, 1), false},
+		{"source", Compute("A.java", "S", "m", lines, 2, 0), Compute("A.java", "T", "m", lines, 2, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.a == tt.b; got != tt.same {
+			t.Errorf("%s: same = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+}
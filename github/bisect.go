@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// isUnprocessableError returns true if GitHub rejected a request with 422,
+// e.g. "pull_request_review_thread.line must be part of the diff".
+func isUnprocessableError(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	return errResp.Response.StatusCode == http.StatusUnprocessableEntity
+}
+
+// partitionDraftComments bisects comments into the ones GitHub accepts and
+// the ones it rejects.
+//
+// Each probe creates a pending review, which is invisible to others, and
+// deletes it right away, so nothing is published while bisecting.
+func (g *PullRequest) partitionDraftComments(ctx context.Context, dcs []*draftComment) (accepted, rejected []*draftComment, err error) {
+	if len(dcs) == 0 {
+		return nil, nil, nil
+	}
+	ok, err := g.probeReview(ctx, dcs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		return dcs, nil, nil
+	}
+	if len(dcs) == 1 {
+		return nil, dcs, nil
+	}
+	mid := len(dcs) / 2
+	a1, r1, err := g.partitionDraftComments(ctx, dcs[:mid])
+	if err != nil {
+		return nil, nil, err
+	}
+	a2, r2, err := g.partitionDraftComments(ctx, dcs[mid:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(a1, a2...), append(r1, r2...), nil
+}
+
+// probeReview returns true if GitHub accepts a review with given comments.
+//
+// Document: https://docs.github.com/en/rest/pulls/reviews?apiVersion=2022-11-28#delete-a-pending-review-for-a-pull-request
+func (g *PullRequest) probeReview(ctx context.Context, dcs []*draftComment) (bool, error) {
+	// A review without an event stays pending.
+	review := &github.PullRequestReviewRequest{
+		CommitID: &g.sha,
+		Comments: drafts(dcs),
+	}
+	r, _, err := g.cli.PullRequests.CreateReview(ctx, g.owner, g.repo, g.pr, review)
+	if err != nil {
+		// GitHub allows a single pending review per user, which would make
+		// every probe fail.
+		if isUnprocessableError(err) && !strings.Contains(err.Error(), "pending review") {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, _, err := g.cli.PullRequests.DeletePendingReview(ctx, g.owner, g.repo, g.pr, r.GetID()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
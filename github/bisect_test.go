@@ -0,0 +1,156 @@
+package github
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/comment"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// badLine is a line GitHub rejects review comments on in fakeReviewServer.
+const badLine = 1000
+
+// fakeReviewServer is a GitHub API which rejects reviews with a comment on
+// badLine or later with 422, like GitHub does for lines outside the diff.
+type fakeReviewServer struct {
+	mu      sync.Mutex
+	nextID  int64
+	posted  []*github.PullRequestReviewRequest
+	probes  int
+	pending map[int64]bool
+}
+
+func (s *fakeReviewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r":
+		fmt.Fprint(w, `{"html_url":"https://github.com/o/r"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/1/comments":
+		fmt.Fprint(w, `[]`)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/pulls/1/reviews":
+		var review github.PullRequestReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, c := range review.Comments {
+			if c.GetLine() >= badLine {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"message":"Unprocessable Entity","errors":["Line could not be resolved"]}`)
+				return
+			}
+		}
+		s.nextID++
+		if review.Event == nil {
+			s.probes++
+			s.pending[s.nextID] = true
+		} else {
+			s.posted = append(s.posted, &review)
+		}
+		fmt.Fprintf(w, `{"id":%d}`, s.nextID)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/repos/o/r/pulls/1/reviews/"):
+		var id int64
+		fmt.Sscan(strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/1/reviews/"), &id)
+		if !s.pending[id] {
+			http.NotFound(w, r)
+			return
+		}
+		delete(s.pending, id)
+		fmt.Fprint(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func testComment(line int) *comment.Comment {
+	return &comment.Comment{
+		Result: &checkstylexml.CheckStyleErrorFormat{
+			File:     "src/A.java",
+			Line:     line,
+			Message:  fmt.Sprintf("violation on line %d", line),
+			Severity: "warning",
+			Source:   "Rule",
+			ErrKey:   fmt.Sprintf("fp%d", line),
+		},
+		ToolName: "checkstyle",
+	}
+}
+
+func TestPostAsReviewCommentRejectedComments(t *testing.T) {
+	srv := &fakeReviewServer{pending: make(map[int64]bool)}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	cli := github.NewClient(ts.Client())
+	cli.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	var comments []*comment.Comment
+	// the first batch has one rejected comment.
+	for i := 1; i <= maxCommentsPerRequest; i++ {
+		line := i
+		if i == 7 {
+			line = badLine
+		}
+		comments = append(comments, testComment(line))
+	}
+	// every comment of the middle batch is rejected.
+	for i := 1; i <= maxCommentsPerRequest; i++ {
+		comments = append(comments, testComment(badLine+i))
+	}
+	// the only comment of the last batch is rejected, which leaves the summary.
+	comments = append(comments, testComment(badLine+maxCommentsPerRequest+1))
+
+	g := &PullRequest{cli: cli, owner: "o", repo: "r", pr: 1, sha: "abc", SplitReviews: true}
+	if err := g.PostAsReviewComment(context.Background(), comments); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.pending) != 0 {
+		t.Errorf("%d pending reviews are left", len(srv.pending))
+	}
+	if srv.probes == 0 {
+		t.Error("rejected reviews were not bisected")
+	}
+	if len(srv.posted) != 2 {
+		t.Fatalf("posted %d reviews, want 2", len(srv.posted))
+	}
+
+	first := srv.posted[0]
+	if got, want := len(first.Comments), maxCommentsPerRequest-1; got != want {
+		t.Errorf("first review has %d comments, want %d", got, want)
+	}
+	for _, c := range first.Comments {
+		if c.GetLine() >= badLine {
+			t.Errorf("first review has rejected comment on line %d", c.GetLine())
+		}
+	}
+
+	last := srv.posted[1]
+	if len(last.Comments) != 0 {
+		t.Errorf("last review has %d comments, want only the summary", len(last.Comments))
+	}
+	if got, want := last.GetEvent(), ReviewEventComment; got != want {
+		t.Errorf("last review event = %q, want %q", got, want)
+	}
+	body := last.GetBody()
+	if !strings.Contains(body, reviewMarker) {
+		t.Errorf("last review body misses the review marker:\n%s", body)
+	}
+	for _, line := range []int{badLine, badLine + 1, badLine + maxCommentsPerRequest + 1} {
+		if msg := fmt.Sprintf("violation on line %d", line); !strings.Contains(body, msg) {
+			t.Errorf("summary does not list rejected comment %q:\n%s", msg, body)
+		}
+	}
+	if got, want := strings.Count(body, "violation on line"), maxCommentsPerRequest+2; got != want {
+		t.Errorf("summary lists %d comments, want %d:\n%s", got, want, body)
+	}
+}
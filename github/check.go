@@ -0,0 +1,171 @@
+package github
+
+import (
+	"checkstyle-review/comment"
+	"checkstyle-review/pathutil"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// maxAnnotationsPerRequest is the maximum number of annotations GitHub
+// accepts per request.
+const maxAnnotationsPerRequest = 50
+
+const defaultCheckName = "checkstyle-review"
+
+// Check is a comment service which reports violations as annotations of a
+// GitHub check run. It works with tokens which cannot create reviews.
+//
+// API:
+//
+//	https://docs.github.com/en/rest/checks/runs?apiVersion=2022-11-28
+//	POST /repos/:owner/:repo/check-runs
+type Check struct {
+	cli   *github.Client
+	owner string
+	repo  string
+	sha   string
+
+	// Name is the name of the check run.
+	Name string
+
+	// DryRun prints requests which write to GitHub instead of sending them
+	// if set.
+	DryRun *DryRun
+}
+
+// NewGitHubCheck returns a new Check service.
+func NewGitHubCheck(cli *github.Client, owner, repo, sha string) *Check {
+	return &Check{
+		cli:   cli,
+		owner: owner,
+		repo:  repo,
+		sha:   sha,
+		Name:  defaultCheckName,
+	}
+}
+
+// Post creates a check run on the commit and adds comments as annotations.
+// Annotations are sent in batches because GitHub accepts at most
+// maxAnnotationsPerRequest annotations per request.
+func (ch *Check) Post(ctx context.Context, postComments []*comment.Comment) error {
+	annotations := make([]*github.CheckRunAnnotation, 0, len(postComments))
+	for _, c := range postComments {
+		annotations = append(annotations, buildCheckRunAnnotation(c))
+	}
+	title := fmt.Sprintf("%d violations", len(annotations))
+	summary := checkRunSummary(postComments)
+
+	createOpts := github.CreateCheckRunOptions{
+		Name:    ch.Name,
+		HeadSHA: ch.sha,
+		Status:  github.String("in_progress"),
+	}
+	var run *github.CheckRun
+	var err error
+	if ch.DryRun != nil {
+		err = ch.DryRun.print("create check run", &createOpts)
+	} else {
+		run, _, err = ch.cli.Checks.CreateCheckRun(ctx, ch.owner, ch.repo, createOpts)
+	}
+	if err != nil {
+		if isPermissionError(err) {
+			log.Printf("Failed to create check run: %v", err)
+			log.Print("fallback to GitHub Actions workflow commands")
+			return reportAsWorkflowCommands(os.Stdout, postComments)
+		}
+		return fmt.Errorf("failed to create check run: %w", err)
+	}
+
+	for i := 0; i < len(annotations) || i == 0; i += maxAnnotationsPerRequest {
+		end := i + maxAnnotationsPerRequest
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		opts := github.UpdateCheckRunOptions{
+			Name: ch.Name,
+			Output: &github.CheckRunOutput{
+				Title:       github.String(title),
+				Summary:     github.String(summary),
+				Annotations: annotations[i:end],
+			},
+		}
+		if end == len(annotations) {
+			opts.Status = github.String("completed")
+			opts.Conclusion = github.String(checkRunConclusion(postComments))
+			opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+		}
+		if ch.DryRun != nil {
+			if err := ch.DryRun.print("update check run", &opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, _, err := ch.cli.Checks.UpdateCheckRun(ctx, ch.owner, ch.repo, run.GetID(), opts); err != nil {
+			return fmt.Errorf("failed to update check run: %w", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Posted annotations: %d\n", len(annotations))
+	return nil
+}
+
+// Document: https://docs.github.com/en/rest/checks/runs?apiVersion=2022-11-28#update-a-check-run
+func buildCheckRunAnnotation(c *comment.Comment) *github.CheckRunAnnotation {
+	cwd, _ := os.Getwd()
+	startLine, endLine := violationLineRange(c)
+	a := &github.CheckRunAnnotation{
+		Path:            github.String(pathutil.NormalizePath(c.Result.File, cwd, "")),
+		StartLine:       github.Int(startLine),
+		EndLine:         github.Int(endLine),
+		AnnotationLevel: github.String(annotationLevel(c)),
+		Message:         github.String(c.Result.Message),
+	}
+	if c.Result.Source != "" {
+		a.Title = github.String(c.Result.Source)
+	}
+	// GitHub API: columns are only accepted on single line annotations.
+	if startLine == endLine && c.Result.Column > 0 {
+		a.StartColumn = github.Int(c.Result.Column)
+		endColumn := c.Result.Column
+		if c.Result.EndColumn > endColumn {
+			endColumn = c.Result.EndColumn
+		}
+		a.EndColumn = github.Int(endColumn)
+	}
+	return a
+}
+
+func annotationLevel(c *comment.Comment) string {
+	switch comment.ParseSeverity(c.Result.Severity) {
+	case comment.SeverityError:
+		return "failure"
+	case comment.SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+func checkRunConclusion(comments []*comment.Comment) string {
+	if hasErrors(comments) {
+		return "failure"
+	}
+	if len(comments) > 0 {
+		return "neutral"
+	}
+	return "success"
+}
+
+func checkRunSummary(comments []*comment.Comment) string {
+	counts := make(map[comment.Severity]int)
+	for _, c := range comments {
+		counts[comment.ParseSeverity(c.Result.Severity)]++
+	}
+	errors, warnings := counts[comment.SeverityError], counts[comment.SeverityWarning]
+	return fmt.Sprintf("🚫 %d errors, ⚠️ %d warnings, 📝 %d others", errors, warnings, len(comments)-errors-warnings)
+}
@@ -0,0 +1,80 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// DryRun prints the requests which would be sent to GitHub instead of
+// sending them. Requests which only read from GitHub are still sent.
+type DryRun struct {
+	W io.Writer
+	// JSON prints requests as JSON lines instead of readable text.
+	JSON bool
+}
+
+// dryRunRequest represents a request printed as JSON.
+type dryRunRequest struct {
+	Action  string      `json:"action"`
+	Request interface{} `json:"request,omitempty"`
+}
+
+func (d *DryRun) print(action string, req interface{}) error {
+	if d.JSON {
+		b, err := json.Marshal(&dryRunRequest{Action: action, Request: req})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(d.W, "%s\n", b)
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("=== [dry-run] %s ===\n", action))
+	switch r := req.(type) {
+	case *github.PullRequestReviewRequest:
+		writeReviewRequest(&sb, r)
+	case nil:
+	default:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		sb.Write(b)
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(d.W, sb.String())
+	return err
+}
+
+func writeReviewRequest(sb *strings.Builder, r *github.PullRequestReviewRequest) {
+	sb.WriteString(fmt.Sprintf("Event: %s\n", r.GetEvent()))
+	sb.WriteString(fmt.Sprintf("Commit: %s\n", r.GetCommitID()))
+	sb.WriteString(fmt.Sprintf("Comments: %d\n", len(r.Comments)))
+	for _, c := range r.Comments {
+		sb.WriteString("\n")
+		if c.GetStartLine() > 0 {
+			sb.WriteString(fmt.Sprintf("%s:%d-%d\n", c.GetPath(), c.GetStartLine(), c.GetLine()))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s:%d\n", c.GetPath(), c.GetLine()))
+		}
+		sb.WriteString(indent(c.GetBody()))
+	}
+	if body := r.GetBody(); body != "" {
+		sb.WriteString("\nBody:\n")
+		sb.WriteString(indent(body))
+	}
+}
+
+func indent(s string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		sb.WriteString("    ")
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -1,14 +1,18 @@
 package github
 
 import (
+	"checkstyle-review/checkstylexml"
 	"checkstyle-review/comment"
+	"checkstyle-review/fingerprint"
 	"checkstyle-review/github/util"
+	"checkstyle-review/pathutil"
 	"context"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v64/github"
 )
@@ -27,6 +31,41 @@ type PullRequest struct {
 	sha              string
 	FallBackToGitCLI bool
 
+	// ResolveFixed resolves review threads posted by this tool once their
+	// violation has been fixed.
+	ResolveFixed bool
+	// ReplyOnResolve replies "Fixed in <sha>" before resolving a thread.
+	ReplyOnResolve bool
+
+	// DryRun prints requests which write to GitHub instead of sending them
+	// if set.
+	DryRun *DryRun
+
+	// SummaryComment keeps a single issue comment with statistics of the
+	// violations up to date. See PostSummary.
+	SummaryComment bool
+
+	// SplitReviews posts comments over maxCommentsPerRequest as more
+	// reviews instead of listing them in the review summary.
+	SplitReviews bool
+	// ReviewDelay is the delay between reviews posted with SplitReviews.
+	ReviewDelay time.Duration
+	// MaxComments caps the total number of inline comments. Comments over
+	// the cap are listed in the review summary. 0 means no cap.
+	MaxComments int
+
+	// ReviewEvent is one of ReviewEventComment (default),
+	// ReviewEventRequestChanges or ReviewEventApprove.
+	ReviewEvent string
+
+	postedcs  comment.PostedComments
+	postedfps comment.PostedFingerprints
+
+	// readOnly is set once the token turned out not to be allowed to write
+	// to the pull request and violations fell back to workflow commands.
+	// Later writes are skipped.
+	readOnly bool
+
 	// wd is working directory relative to root of repository.
 	wd string
 }
@@ -57,10 +96,16 @@ func NewGitHubPullRequest(cli *github.Client, owner, repo string, pr int, sha st
 	}, nil
 }
 
+// Post posts comments as a review of the pull request.
+func (g *PullRequest) Post(ctx context.Context, postComments []*comment.Comment) error {
+	return g.PostAsReviewComment(ctx, postComments)
+}
+
 func (g *PullRequest) PostAsReviewComment(ctx context.Context, postComments []*comment.Comment) error {
 
-	reviewComments := make([]*github.DraftReviewComment, 0, len(postComments))
+	reviewComments := make([]*draftComment, 0, len(postComments))
 	remaining := make([]*comment.Comment, 0)
+	outside := make([]*comment.Comment, 0)
 	rootPath, err := util.GetGitRoot()
 	if err != nil {
 		return err
@@ -69,41 +114,136 @@ func (g *PullRequest) PostAsReviewComment(ctx context.Context, postComments []*c
 	if err != nil {
 		return err
 	}
+	if err := g.setPostedComment(ctx); err != nil {
+		return err
+	}
 	for _, c := range postComments {
-
-		// Only posts maxCommentsPerRequest comments per 1 request to avoid spammy
-		// review comments. An example GitHub error if we don't limit the # of
-		// review comments.
-		//
-		// > 403 You have triggered an abuse detection mechanism and have been
-		// > temporarily blocked from content creation. Please retry your request
-		// > again later.
-		// https://docs.github.com/en/rest/overview/resources-in-the-rest-api?apiVersion=2022-11-28#rate-limiting
-		if len(reviewComments) >= maxCommentsPerRequest {
-			remaining = append(remaining, c)
+		if c.OutsideDiff {
+			// GitHub does not accept review comments outside the diff.
+			outside = append(outside, c)
 			continue
 		}
-		comment := buildDraftReviewComment(c, buildBody(c, repoBaseHTMLURL, rootPath))
-		reviewComments = append(reviewComments, comment)
-
+		draft := buildDraftReviewComment(c, buildBody(c, repoBaseHTMLURL, rootPath))
+		if g.postedfps.IsPosted(c.Result.ErrKey) ||
+			g.postedcs.IsPosted(draft.GetPath(), draft.GetLine(), comment.MarkdownComment(c)) {
+			// it's already posted. skip it.
+			continue
+		}
+		reviewComments = append(reviewComments, &draftComment{c: c, draft: draft})
 	}
 
-	if len(reviewComments) > 0 || len(remaining) > 0 {
-		// send review comments to GitHub.
-		review := &github.PullRequestReviewRequest{
-			CommitID: &g.sha,
-			Event:    github.String("COMMENT"),
-			Comments: reviewComments,
-			Body:     github.String(g.remainingCommentsSummary(remaining, repoBaseHTMLURL, rootPath)),
+	// Only posts maxCommentsPerRequest comments per 1 request to avoid spammy
+	// review comments. An example GitHub error if we don't limit the # of
+	// review comments.
+	//
+	// > 403 You have triggered an abuse detection mechanism and have been
+	// > temporarily blocked from content creation. Please retry your request
+	// > again later.
+	// https://docs.github.com/en/rest/overview/resources-in-the-rest-api?apiVersion=2022-11-28#rate-limiting
+	//
+	// With SplitReviews, the overflow is posted as more reviews instead, which
+	// are paced by ReviewDelay.
+	limit := maxCommentsPerRequest
+	if g.SplitReviews {
+		limit = len(reviewComments)
+	}
+	if g.MaxComments > 0 && limit > g.MaxComments {
+		limit = g.MaxComments
+	}
+	if len(reviewComments) > limit {
+		for _, dc := range reviewComments[limit:] {
+			remaining = append(remaining, dc.c)
 		}
+		reviewComments = reviewComments[:limit]
+	}
+	batches := chunkDraftComments(reviewComments, maxCommentsPerRequest)
 
-		fmt.Printf("Review comment body: %s\n", review.Comments)
-		_, _, err := g.cli.PullRequests.CreateReview(ctx, g.owner, g.repo, g.pr, review)
+	// Already posted violations count as well, so a previous review which
+	// requested changes is only dismissed once every error is fixed.
+	foundErrors := hasErrors(postComments)
+	event := g.reviewEvent(foundErrors)
+	if !foundErrors && g.ReviewEvent != "" && g.ReviewEvent != ReviewEventComment {
+		dismissed, err := g.dismissChangesRequested(ctx)
 		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Dismissed reviews: %d\n", dismissed)
+	}
+	if g.readOnly {
+		return g.fallbackToWorkflowCommands(postComments)
+	}
+
+	if len(batches) == 0 && (len(remaining) > 0 || len(outside) > 0 || event == ReviewEventApprove) {
+		// post the summary only. An approval is posted even without
+		// comments, e.g. on a clean first run.
+		batches = [][]*draftComment{nil}
+	}
+	rejected := make([]*comment.Comment, 0)
+	for i, batch := range batches {
+		if i > 0 && g.DryRun == nil {
+			if err := sleep(ctx, g.ReviewDelay); err != nil {
+				return err
+			}
+		}
+		// The event and the summary belong to the last review.
+		last := i == len(batches)-1
+		for bisected := false; ; bisected = true {
+			if len(batch) == 0 && !last {
+				// every comment of the batch has been rejected.
+				break
+			}
+			review := &github.PullRequestReviewRequest{
+				CommitID: &g.sha,
+				Event:    github.String(ReviewEventComment),
+				Comments: drafts(batch),
+				Body:     github.String(reviewMarker),
+			}
+			if last {
+				review.Event = github.String(event)
+				review.Body = github.String(truncateReviewBody(reviewMarker + "\n" +
+					g.remainingCommentsSummary(remaining, repoBaseHTMLURL, rootPath) +
+					g.outsideDiffCommentsSummary(outside, repoBaseHTMLURL, rootPath) +
+					g.rejectedCommentsSummary(rejected, repoBaseHTMLURL, rootPath)))
+			}
+
+			if g.DryRun != nil {
+				if err := g.DryRun.print("create review", review); err != nil {
+					return err
+				}
+				break
+			}
+
+			// send review comments to GitHub.
+			fmt.Fprintf(os.Stderr, "Review comment body: %s\n", review.Comments)
+			_, _, err := g.cli.PullRequests.CreateReview(ctx, g.owner, g.repo, g.pr, review)
+			if err == nil {
+				break
+			}
 			log.Printf("Failed to post a review comment: %v", err)
 			// GitHub returns 403 or 404 if we don't have permission to post a review comment.
 			// fallback to log message in this case.
-			return err
+			if isPermissionError(err) {
+				return g.fallbackToWorkflowCommands(postComments)
+			}
+			// GitHub returns 422 for the whole review if a single comment is
+			// not on a line it accepts, e.g. for renamed files or lines past
+			// EOF. Find such comments and post the others.
+			if bisected || len(batch) == 0 || !isUnprocessableError(err) {
+				return err
+			}
+			accepted, bad, perr := g.partitionDraftComments(ctx, batch)
+			if perr != nil {
+				return perr
+			}
+			if len(bad) == 0 {
+				// the review was not rejected because of its comments.
+				return err
+			}
+			log.Printf("GitHub rejected %d comments, posting the others", len(bad))
+			for _, dc := range bad {
+				rejected = append(rejected, dc.c)
+			}
+			batch = accepted
 		}
 	}
 
@@ -111,12 +251,61 @@ func (g *PullRequest) PostAsReviewComment(ctx context.Context, postComments []*c
 
 }
 
+// fallbackToWorkflowCommands reports comments as workflow commands because
+// the token cannot write to the pull request, and marks the service read-only.
+func (g *PullRequest) fallbackToWorkflowCommands(postComments []*comment.Comment) error {
+	log.Print("fallback to GitHub Actions workflow commands")
+	g.readOnly = true
+	return reportAsWorkflowCommands(os.Stdout, postComments)
+}
+
+// draftComment is a review comment draft with the comment it was built from.
+type draftComment struct {
+	c     *comment.Comment
+	draft *github.DraftReviewComment
+}
+
+func drafts(dcs []*draftComment) []*github.DraftReviewComment {
+	ds := make([]*github.DraftReviewComment, 0, len(dcs))
+	for _, dc := range dcs {
+		ds = append(ds, dc.draft)
+	}
+	return ds
+}
+
+func chunkDraftComments(dcs []*draftComment, size int) [][]*draftComment {
+	var chunks [][]*draftComment
+	for len(dcs) > size {
+		chunks = append(chunks, dcs[:size])
+		dcs = dcs[size:]
+	}
+	if len(dcs) > 0 {
+		chunks = append(chunks, dcs)
+	}
+	return chunks
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 // Document: https://docs.github.com/en/rest/reference/pulls#create-a-review-comment-for-a-pull-request
 func buildDraftReviewComment(c *comment.Comment, body string) *github.DraftReviewComment {
 	cwd, _ := os.Getwd()
 	startLine, endLine := githubCommentLineRange(c)
 	r := &github.DraftReviewComment{
-		Path: github.String(NormalizePath(c.Result.File, cwd, "")),
+		Path: github.String(pathutil.NormalizePath(c.Result.File, cwd, "")),
 		Side: github.String("RIGHT"),
 		Body: github.String(body),
 		Line: github.Int(endLine),
@@ -129,29 +318,85 @@ func buildDraftReviewComment(c *comment.Comment, body string) *github.DraftRevie
 	return r
 }
 
+// githubCommentLineRange returns the lines a review comment spans, which are
+// limited to a single hunk.
 func githubCommentLineRange(c *comment.Comment) (start int, end int) {
 	startLine := c.Result.Line
-	endLine := 0
-	endLine = startLine
+	endLine := startLine
+	if c.EndLine > startLine {
+		endLine = c.EndLine
+	}
+	return startLine, endLine
+}
+
+// violationLineRange returns all lines of a violation. Annotations are not
+// limited to the diff, so they span the whole range.
+func violationLineRange(c *comment.Comment) (start int, end int) {
+	startLine := c.Result.Line
+	endLine := startLine
+	if c.Result.EndLine > startLine {
+		endLine = c.Result.EndLine
+	}
 	return startLine, endLine
 }
 
 func (g *PullRequest) remainingCommentsSummary(remaining []*comment.Comment, baseURL string, gitRootPath string) string {
-	if len(remaining) == 0 {
+	return commentsSummary("Remaining comments which cannot be posted as a review comment to avoid GitHub Rate Limit", remaining, baseURL, gitRootPath)
+}
+
+func (g *PullRequest) rejectedCommentsSummary(rejected []*comment.Comment, baseURL string, gitRootPath string) string {
+	return commentsSummary("Comments which GitHub rejected because their line is not part of the diff", rejected, baseURL, gitRootPath)
+}
+
+func (g *PullRequest) outsideDiffCommentsSummary(outside []*comment.Comment, baseURL string, gitRootPath string) string {
+	return commentsSummary("Comments on lines outside the diff which cannot be posted as a review comment", outside, baseURL, gitRootPath)
+}
+
+// maxSummaryComments caps the number of comments listed by commentsSummary.
+const maxSummaryComments = 100
+
+// maxReviewBodyLength is the maximum length of a review body. GitHub rejects
+// bodies over 65536 characters.
+const maxReviewBodyLength = 65000
+
+// truncateReviewBody cuts body to maxReviewBodyLength bytes, so that a body
+// with many long messages is not rejected as a whole.
+func truncateReviewBody(body string) string {
+	const note = "\n\n... the summary was truncated"
+	if len(body) <= maxReviewBodyLength {
+		return body
+	}
+	cut := maxReviewBodyLength - len(note)
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut] + note
+}
+
+func commentsSummary(title string, comments []*comment.Comment, baseURL string, gitRootPath string) string {
+	if len(comments) == 0 {
 		return ""
 	}
+	listed := 0
 	perTool := make(map[string][]*comment.Comment)
-	for _, c := range remaining {
+	for _, c := range comments {
 		perTool[c.ToolName] = append(perTool[c.ToolName], c)
 	}
 	var sb strings.Builder
-	sb.WriteString("Remaining comments which cannot be posted as a review comment to avoid GitHub Rate Limit\n")
+	sb.WriteString(title + "\n")
 	sb.WriteString("\n")
 	for tool, comments := range perTool {
+		if listed == maxSummaryComments {
+			break
+		}
 		sb.WriteString("<details>\n")
 		sb.WriteString(fmt.Sprintf("<summary>%s</summary>\n", tool))
 		sb.WriteString("\n")
 		for _, c := range comments {
+			if listed == maxSummaryComments {
+				break
+			}
+			listed++
 			sb.WriteString("<hr>")
 			sb.WriteString("\n")
 			sb.WriteString("\n")
@@ -164,6 +409,9 @@ func (g *PullRequest) remainingCommentsSummary(remaining []*comment.Comment, bas
 		}
 		sb.WriteString("</details>\n")
 	}
+	if rest := len(comments) - listed; rest > 0 {
+		sb.WriteString(fmt.Sprintf("\n... and %d more\n", rest))
+	}
 	return sb.String()
 }
 
@@ -180,6 +428,30 @@ func (g *PullRequest) repoBaseHTMLURL(ctx context.Context) (string, error) {
 	return repo.GetHTMLURL() + "/blob/" + g.sha, nil
 }
 
+// setPostedComment loads review comments which are already posted on the
+// pull request so that the same violations are not posted again.
+func (g *PullRequest) setPostedComment(ctx context.Context) error {
+	g.postedcs = make(comment.PostedComments)
+	g.postedfps = make(comment.PostedFingerprints)
+	cs, err := g.comment(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range cs {
+		// Fingerprints survive line shifts, so outdated comments count as well.
+		if fp, ok := fingerprint.Extract(c.GetBody()); ok {
+			g.postedfps.AddPostedFingerprint(fp)
+		}
+		if c.Line == nil || c.Path == nil || c.Body == nil {
+			// skip outdated comments. Or comments which do not have "path" nor
+			// "body".
+			continue
+		}
+		g.postedcs.AddPostedComment(c.GetPath(), c.GetLine(), c.GetBody())
+	}
+	return nil
+}
+
 func (g *PullRequest) comment(ctx context.Context) ([]*github.PullRequestComment, error) {
 	// https://developer.github.com/v3/guides/traversing-with-pagination/
 	opts := &github.PullRequestListCommentsOptions{
@@ -222,53 +494,25 @@ func buildBody(c *comment.Comment, baseURL string, gitRootPath string) string {
 		snippetURL := githubCodeSnippetURL(baseURL, gitRootPath, c.Result.File, c.Result.Line)
 		cbody += "\n\n" + snippetURL
 	}
-	return cbody
+	markers := fingerprint.RuleMarker(ruleKey(c.Result))
+	if fp := c.Result.ErrKey; fp != "" {
+		markers = fingerprint.Marker(fp) + "\n" + markers
+	}
+	return cbody + "\n\n" + markers
+}
+
+// ruleKey returns the rule key of a violation at the path of its review
+// comment.
+func ruleKey(res *checkstylexml.CheckStyleErrorFormat) string {
+	cwd, _ := os.Getwd()
+	return fingerprint.RuleKey(pathutil.NormalizePath(res.File, cwd, ""), res.Source, res.Message)
 }
 
 func githubCodeSnippetURL(baseURL, gitRootPath string, location string, start int) string {
-	relPath := NormalizePath(location, gitRootPath, "")
+	relPath := pathutil.NormalizePath(location, gitRootPath, "")
 	relatedURL := fmt.Sprintf("%s/%s", baseURL, relPath)
 	if startLine := start; startLine > 0 {
 		relatedURL += fmt.Sprintf("#L%d", startLine)
 	}
 	return relatedURL
 }
-
-// NormalizePath return normalized path with workdir and relative path to
-// project.
-func NormalizePath(path, workdir, projectRelPath string) string {
-	path = filepath.Clean(path)
-	if path == "." {
-		return ""
-	}
-	// Convert absolute path to relative path only if the path is in current
-	// directory.
-	if filepath.IsAbs(path) && workdir != "" && contains(path, workdir) {
-		relPath, err := filepath.Rel(workdir, path)
-		if err == nil {
-			path = relPath
-		}
-	}
-	if !filepath.IsAbs(path) && projectRelPath != "" {
-		path = filepath.Join(projectRelPath, path)
-	}
-	return filepath.ToSlash(path)
-}
-
-func contains(path, base string) bool {
-	ps := splitPathList(path)
-	bs := splitPathList(base)
-	if len(ps) < len(bs) {
-		return false
-	}
-	for i := range bs {
-		if bs[i] != ps[i] {
-			return false
-		}
-	}
-	return true
-}
-
-func splitPathList(path string) []string {
-	return strings.Split(filepath.ToSlash(path), "/")
-}
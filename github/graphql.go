@@ -0,0 +1,64 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// graphQLRequest represents a request to GitHub GraphQL API.
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse represents a response of GitHub GraphQL API.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQL sends a query to GitHub GraphQL API and decodes its data into out.
+//
+// Document: https://docs.github.com/en/graphql/guides/forming-calls-with-graphql
+func (g *PullRequest) graphQL(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	req, err := g.cli.NewRequest(http.MethodPost, graphQLEndpoint(g.cli.BaseURL), &graphQLRequest{Query: query, Variables: vars})
+	if err != nil {
+		return err
+	}
+	var resp graphQLResponse
+	if _, err := g.cli.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		errs := make([]error, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			errs = append(errs, errors.New(e.Message))
+		}
+		return fmt.Errorf("GitHub GraphQL API error: %w", errors.Join(errs...))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// graphQLEndpoint returns GraphQL API endpoint for a REST API base URL.
+//
+//	https://api.github.com/         -> https://api.github.com/graphql
+//	https://example.com/api/v3/     -> https://example.com/api/graphql (GitHub Enterprise Server)
+func graphQLEndpoint(baseURL *url.URL) string {
+	u := *baseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/graphql"
+	return u.String()
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"checkstyle-review/comment"
+	"checkstyle-review/github/util"
+	"checkstyle-review/pathutil"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// maxJobSummaryComments caps the number of violations listed in a job summary,
+// which GitHub limits to 1MiB per step.
+const maxJobSummaryComments = 1000
+
+// JobSummary writes job summaries to W with files linked under BaseURL.
+type JobSummary struct {
+	W       io.Writer
+	BaseURL string
+}
+
+// WriteJobSummary writes comments as a job summary. See WriteJobSummary.
+func (s *JobSummary) WriteJobSummary(comments []*comment.Comment) error {
+	return WriteJobSummary(s.W, comments, s.BaseURL)
+}
+
+// WriteJobSummary writes comments grouped by file as a Markdown job summary.
+// baseURL is the blob URL of the commit, e.g.
+// https://github.com/owner/repo/blob/<sha>, and links are omitted if it is
+// empty.
+//
+// Document: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary
+func WriteJobSummary(w io.Writer, comments []*comment.Comment, baseURL string) error {
+	rootPath, err := util.GetGitRoot()
+	if err != nil {
+		return err
+	}
+	perFile := make(map[string][]*comment.Comment)
+	for _, c := range comments {
+		path := pathutil.NormalizePath(c.Result.File, rootPath, "")
+		perFile[path] = append(perFile[path], c)
+	}
+	files := make([]string, 0, len(perFile))
+	for f := range perFile {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	var sb strings.Builder
+	sb.WriteString("## Checkstyle review\n\n")
+	sb.WriteString(fmt.Sprintf("**%d** violations in **%d** files.\n\n", len(comments), len(files)))
+	written := 0
+	for _, f := range files {
+		if written >= maxJobSummaryComments {
+			break
+		}
+		cs := perFile[f]
+		sort.SliceStable(cs, func(i, j int) bool { return cs[i].Result.Line < cs[j].Result.Line })
+		sb.WriteString(fmt.Sprintf("### `%s`\n\n", f))
+		for _, c := range cs {
+			if written >= maxJobSummaryComments {
+				break
+			}
+			sb.WriteString("- ")
+			sb.WriteString(comment.MarkdownComment(c))
+			if baseURL != "" {
+				sb.WriteString(fmt.Sprintf(" ([L%d](%s))", c.Result.Line, githubCodeSnippetURL(baseURL, rootPath, c.Result.File, c.Result.Line)))
+			} else {
+				sb.WriteString(fmt.Sprintf(" (L%d)", c.Result.Line))
+			}
+			sb.WriteString("\n")
+			written++
+		}
+		sb.WriteString("\n")
+	}
+	if rest := len(comments) - written; rest > 0 {
+		sb.WriteString(fmt.Sprintf("... and %d more violations.\n", rest))
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
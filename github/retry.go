@@ -0,0 +1,160 @@
+package github
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = time.Minute
+)
+
+// retryTransport is a http.RoundTripper which retries requests rejected by
+// GitHub primary or secondary rate limits.
+//
+// Document: https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api?apiVersion=2022-11-28#exceeding-the-rate-limit
+type retryTransport struct {
+	base   http.RoundTripper
+	budget time.Duration
+
+	// waited is the total time spent waiting for retries of all requests.
+	mu     sync.Mutex
+	waited time.Duration
+}
+
+// NewRetryTransport returns a http.RoundTripper which retries rate limited
+// requests with exponential backoff and jitter, as long as the total waiting
+// time of all requests sent through it stays within budget. It honors
+// Retry-After and X-RateLimit-Reset headers. A zero budget disables retries.
+func NewRetryTransport(base http.RoundTripper, budget time.Duration) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, budget: budget}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		wait, ok := retryDelay(resp, attempt)
+		if !ok || !t.reserve(wait) {
+			return resp, nil
+		}
+		next, err := rewind(req)
+		if err != nil || next == nil {
+			return resp, nil
+		}
+		// drain the body so that the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		log.Printf("GitHub rate limit exceeded: %s %s returned %d, retrying in %s", req.Method, req.URL.Path, resp.StatusCode, wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// reserve takes wait from the remaining budget. It returns false if the
+// budget does not cover wait.
+func (t *retryTransport) reserve(wait time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.waited+wait > t.budget {
+		return false
+	}
+	t.waited += wait
+	return true
+}
+
+// rewind returns a copy of req which can be sent again. It returns nil if the
+// body of req cannot be read again.
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// retryDelay returns how long to wait before retrying a response. It returns
+// false if the response should not be retried.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if d, ok := retryAfter(resp.Header); ok {
+		return d + jitter(retryBaseDelay), true
+	}
+	// CheckResponse re-populates the body, so the response can still be
+	// returned to the caller.
+	switch err := github.CheckResponse(resp).(type) {
+	case *github.RateLimitError:
+		return time.Until(err.Rate.Reset.Time) + jitter(retryBaseDelay), true
+	case *github.AbuseRateLimitError:
+		if err.RetryAfter != nil {
+			return *err.RetryAfter + jitter(retryBaseDelay), true
+		}
+		return backoff(attempt), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return backoff(attempt), true
+	}
+	// Other 403 responses are permission errors which never succeed.
+	return 0, false
+}
+
+// retryAfter parses Retry-After header, which is either seconds or a date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// backoff returns an exponential delay with jitter for the attempt.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 6 {
+		d = min(retryBaseDelay<<attempt, retryMaxDelay)
+	}
+	return d/2 + jitter(d/2)
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func response(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/repos/o/r/pulls/1/reviews", nil)
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second)
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		ok       bool
+		min, max time.Duration
+	}{
+		{
+			name: "Retry-After seconds",
+			resp: response(http.StatusForbidden, http.Header{"Retry-After": {"30"}}, `{"message":"You have exceeded a secondary rate limit."}`),
+			ok:   true, min: 30 * time.Second, max: 31 * time.Second,
+		},
+		{
+			name: "Retry-After on 429",
+			resp: response(http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}}, ""),
+			ok:   true, min: 5 * time.Second, max: 6 * time.Second,
+		},
+		{
+			name: "X-RateLimit-Reset",
+			resp: response(http.StatusForbidden, http.Header{
+				"X-Ratelimit-Limit":     {"5000"},
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+			}, `{"message":"API rate limit exceeded."}`),
+			// the reset time has a resolution of seconds.
+			ok: true, min: 28 * time.Second, max: 31 * time.Second,
+		},
+		{
+			name: "429 without headers",
+			resp: response(http.StatusTooManyRequests, nil, ""),
+			ok:   true, min: retryBaseDelay / 2, max: retryBaseDelay,
+		},
+		{
+			name: "permission error",
+			resp: response(http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`),
+		},
+		{
+			name: "server error",
+			resp: response(http.StatusInternalServerError, nil, ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryDelay(tt.resp, 0)
+			if ok != tt.ok {
+				t.Fatalf("retryDelay() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (got < tt.min || got > tt.max) {
+				t.Errorf("retryDelay() = %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tr := &retryTransport{budget: 10 * time.Second}
+	steps := []struct {
+		wait time.Duration
+		want bool
+	}{
+		{6 * time.Second, true},
+		{5 * time.Second, false},
+		{4 * time.Second, true},
+		{time.Millisecond, false},
+	}
+	for i, s := range steps {
+		if got := tr.reserve(s.wait); got != s.want {
+			t.Errorf("step %d: reserve(%s) = %v, want %v", i, s.wait, got, s.want)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("request %d has body %q, want %q", calls.Load(), body, "payload")
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		budget    time.Duration
+		wantCode  int
+		wantCalls int32
+	}{
+		{"retried", time.Minute, http.StatusOK, 2},
+		{"depleted budget", 0, http.StatusTooManyRequests, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			cli := &http.Client{Transport: NewRetryTransport(nil, tt.budget)}
+			resp, err := cli.Post(ts.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package github
+
+import (
+	"checkstyle-review/comment"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// Review events.
+//
+// Document: https://docs.github.com/en/rest/pulls/reviews?apiVersion=2022-11-28#create-a-review-for-a-pull-request
+const (
+	// ReviewEventComment always posts reviews as comments.
+	ReviewEventComment = "COMMENT"
+	// ReviewEventRequestChanges requests changes when error level violations
+	// are found and comments otherwise.
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+	// ReviewEventApprove requests changes when error level violations are
+	// found and approves otherwise.
+	ReviewEventApprove = "APPROVE"
+)
+
+// reviewMarker is embedded in the body of reviews posted by this tool, so
+// that later runs can find them.
+const reviewMarker = "<!-- checkstyle-review -->"
+
+// ParseReviewEvent validates a review event name.
+func ParseReviewEvent(s string) (string, error) {
+	switch e := strings.ToUpper(s); e {
+	case ReviewEventComment, ReviewEventRequestChanges, ReviewEventApprove:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown review event: %q", s)
+	}
+}
+
+// reviewEvent returns the event of a review to post.
+func (g *PullRequest) reviewEvent(hasErrors bool) string {
+	switch g.ReviewEvent {
+	case ReviewEventRequestChanges:
+		if hasErrors {
+			return ReviewEventRequestChanges
+		}
+		return ReviewEventComment
+	case ReviewEventApprove:
+		if hasErrors {
+			return ReviewEventRequestChanges
+		}
+		return ReviewEventApprove
+	default:
+		return ReviewEventComment
+	}
+}
+
+func hasErrors(comments []*comment.Comment) bool {
+	for _, c := range comments {
+		if comment.ParseSeverity(c.Result.Severity) == comment.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// dismissChangesRequested dismisses reviews of this tool which requested
+// changes. It returns the number of dismissed reviews. It marks the service
+// read-only instead of failing if the token may not dismiss reviews.
+//
+// Document: https://docs.github.com/en/rest/pulls/reviews?apiVersion=2022-11-28#dismiss-a-review-for-a-pull-request
+func (g *PullRequest) dismissChangesRequested(ctx context.Context) (int, error) {
+	reviews, err := g.reviews(ctx)
+	if err != nil {
+		return 0, err
+	}
+	dismissed := 0
+	for _, r := range reviews {
+		if r.GetState() != "CHANGES_REQUESTED" || !strings.Contains(r.GetBody(), reviewMarker) {
+			continue
+		}
+		req := &github.PullRequestReviewDismissalRequest{
+			Message: github.String("All checkstyle errors have been fixed."),
+		}
+		if g.DryRun != nil {
+			if err := g.DryRun.print(fmt.Sprintf("dismiss review %d", r.GetID()), req); err != nil {
+				return dismissed, err
+			}
+			dismissed++
+			continue
+		}
+		if _, _, err := g.cli.PullRequests.DismissReview(ctx, g.owner, g.repo, g.pr, r.GetID(), req); err != nil {
+			if isPermissionError(err) {
+				// the token is read-only, so nothing can be posted either.
+				log.Printf("Failed to dismiss review %d: %v", r.GetID(), err)
+				g.readOnly = true
+				return dismissed, nil
+			}
+			return dismissed, fmt.Errorf("failed to dismiss review %d: %w", r.GetID(), err)
+		}
+		dismissed++
+	}
+	return dismissed, nil
+}
+
+func (g *PullRequest) reviews(ctx context.Context) ([]*github.PullRequestReview, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var all []*github.PullRequestReview
+	for {
+		reviews, resp, err := g.cli.PullRequests.ListReviews(ctx, g.owner, g.repo, g.pr, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, reviews...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
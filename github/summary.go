@@ -0,0 +1,73 @@
+package github
+
+import (
+	"checkstyle-review/comment"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// summaryMarker is embedded in the summary comment, so that later runs can
+// update it in place.
+const summaryMarker = "<!-- checkstyle-review:summary -->"
+
+// PostSummary creates or updates the sticky summary comment of the pull
+// request. It does nothing unless SummaryComment is set, or after violations
+// fell back to workflow commands.
+//
+// Document: https://docs.github.com/en/rest/issues/comments?apiVersion=2022-11-28
+func (g *PullRequest) PostSummary(ctx context.Context, s *comment.Summary) error {
+	if !g.SummaryComment || g.readOnly {
+		return nil
+	}
+	body := summaryMarker + "\n" + s.Markdown()
+	existing, err := g.summaryComment(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find summary comment: %w", err)
+	}
+	if existing != nil {
+		if existing.GetBody() == body {
+			return nil
+		}
+		if g.DryRun != nil {
+			return g.DryRun.print(fmt.Sprintf("update summary comment %d", existing.GetID()), &github.IssueComment{Body: github.String(body)})
+		}
+		_, _, err := g.cli.Issues.EditComment(ctx, g.owner, g.repo, existing.GetID(), &github.IssueComment{Body: github.String(body)})
+		if err != nil {
+			return fmt.Errorf("failed to update summary comment: %w", err)
+		}
+		return nil
+	}
+	if g.DryRun != nil {
+		return g.DryRun.print("create summary comment", &github.IssueComment{Body: github.String(body)})
+	}
+	_, _, err = g.cli.Issues.CreateComment(ctx, g.owner, g.repo, g.pr, &github.IssueComment{Body: github.String(body)})
+	if err != nil {
+		return fmt.Errorf("failed to create summary comment: %w", err)
+	}
+	return nil
+}
+
+// summaryComment returns the summary comment posted by a previous run, or nil.
+func (g *PullRequest) summaryComment(ctx context.Context) (*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		comments, resp, err := g.cli.Issues.ListComments(ctx, g.owner, g.repo, g.pr, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.GetBody(), summaryMarker) {
+				return c, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,176 @@
+package github
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/fingerprint"
+	"context"
+	"fmt"
+	"os"
+)
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $pr: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $pr) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          isResolved
+          comments(first: 1) {
+            nodes {
+              body
+              viewerDidAuthor
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) {
+    thread {
+      id
+    }
+  }
+}`
+
+const addReviewThreadReplyMutation = `mutation($id: ID!, $body: String!) {
+  addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $id, body: $body}) {
+    comment {
+      id
+    }
+  }
+}`
+
+// reviewThread represents a review thread of a pull request.
+type reviewThread struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+	Comments   struct {
+		Nodes []struct {
+			Body            string `json:"body"`
+			ViewerDidAuthor bool   `json:"viewerDidAuthor"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+// fingerprint returns the fingerprint of the violation which started the
+// thread. It returns false if the thread was not started by this tool.
+func (t *reviewThread) fingerprint() (string, bool) {
+	if len(t.Comments.Nodes) == 0 || !t.Comments.Nodes[0].ViewerDidAuthor {
+		return "", false
+	}
+	return fingerprint.Extract(t.Comments.Nodes[0].Body)
+}
+
+// ruleKey returns the rule key of the violation which started the thread.
+// Threads posted by older versions have no rule key.
+func (t *reviewThread) ruleKey() (string, bool) {
+	if len(t.Comments.Nodes) == 0 {
+		return "", false
+	}
+	return fingerprint.ExtractRuleKey(t.Comments.Nodes[0].Body)
+}
+
+// isFixed reports whether no current violation matches the thread, neither by
+// fingerprint nor by path, source and message. The latter keeps threads open
+// whose fingerprint changed because lines around the violation were edited.
+func (t *reviewThread) isFixed(fps, ruleKeys map[string]bool) bool {
+	fp, ok := t.fingerprint()
+	if !ok || fps[fp] {
+		return false
+	}
+	key, ok := t.ruleKey()
+	return !ok || !ruleKeys[key]
+}
+
+// ResolveFixedThreads resolves unresolved review threads posted by this tool
+// whose violation is not among current violations anymore.
+// It does nothing unless ResolveFixed is set, or after violations fell back to
+// workflow commands.
+//
+// If ReplyOnResolve is set, it replies "Fixed in <sha>" before resolving a
+// thread.
+func (g *PullRequest) ResolveFixedThreads(ctx context.Context, current []*checkstylexml.CheckStyleErrorFormat) error {
+	if !g.ResolveFixed || g.readOnly {
+		return nil
+	}
+	fps := make(map[string]bool, len(current))
+	ruleKeys := make(map[string]bool, len(current))
+	for _, res := range current {
+		fps[res.ErrKey] = true
+		ruleKeys[ruleKey(res)] = true
+	}
+	threads, err := g.reviewThreads(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list review threads: %w", err)
+	}
+	resolved := 0
+	for _, t := range threads {
+		if t.IsResolved {
+			continue
+		}
+		if !t.isFixed(fps, ruleKeys) {
+			continue
+		}
+		if g.DryRun != nil {
+			if err := g.DryRun.print("resolve review thread "+t.ID, nil); err != nil {
+				return err
+			}
+			resolved++
+			continue
+		}
+		if g.ReplyOnResolve {
+			vars := map[string]interface{}{"id": t.ID, "body": fmt.Sprintf("Fixed in %s", g.sha)}
+			if err := g.graphQL(ctx, addReviewThreadReplyMutation, vars, nil); err != nil {
+				return fmt.Errorf("failed to reply to review thread %s: %w", t.ID, err)
+			}
+		}
+		if err := g.graphQL(ctx, resolveReviewThreadMutation, map[string]interface{}{"id": t.ID}, nil); err != nil {
+			return fmt.Errorf("failed to resolve review thread %s: %w", t.ID, err)
+		}
+		resolved++
+	}
+	fmt.Fprintf(os.Stderr, "Resolved review threads: %d\n", resolved)
+	return nil
+}
+
+func (g *PullRequest) reviewThreads(ctx context.Context) ([]*reviewThread, error) {
+	var threads []*reviewThread
+	var cursor *string
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []*reviewThread `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		vars := map[string]interface{}{
+			"owner":  g.owner,
+			"repo":   g.repo,
+			"pr":     g.pr,
+			"cursor": cursor,
+		}
+		if err := g.graphQL(ctx, reviewThreadsQuery, vars, &data); err != nil {
+			return nil, err
+		}
+		rt := data.Repository.PullRequest.ReviewThreads
+		threads = append(threads, rt.Nodes...)
+		if !rt.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		cursor = &rt.PageInfo.EndCursor
+	}
+}
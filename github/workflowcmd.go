@@ -0,0 +1,81 @@
+package github
+
+import (
+	"checkstyle-review/comment"
+	"checkstyle-review/pathutil"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// isPermissionError returns true if GitHub rejected a request because the
+// token is not allowed to write, e.g. on a pull request from a fork.
+// GitHub returns 403 or 404 in this case.
+func isPermissionError(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	code := errResp.Response.StatusCode
+	return code == http.StatusForbidden || code == http.StatusNotFound
+}
+
+// reportAsWorkflowCommands writes comments as GitHub Actions workflow
+// commands, which GitHub shows as annotations of the workflow run.
+//
+// Document: https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+func reportAsWorkflowCommands(w io.Writer, comments []*comment.Comment) error {
+	cwd, _ := os.Getwd()
+	for _, c := range comments {
+		startLine, endLine := violationLineRange(c)
+		props := []string{
+			"file=" + escapeWorkflowProperty(pathutil.NormalizePath(c.Result.File, cwd, "")),
+			fmt.Sprintf("line=%d", startLine),
+			fmt.Sprintf("endLine=%d", endLine),
+		}
+		if c.Result.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", c.Result.Column))
+			if c.Result.EndColumn > 0 && startLine == endLine {
+				props = append(props, fmt.Sprintf("endColumn=%d", c.Result.EndColumn))
+			}
+		}
+		if c.Result.Source != "" {
+			props = append(props, "title="+escapeWorkflowProperty(c.Result.Source))
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", workflowCommand(c), strings.Join(props, ","), escapeWorkflowData(c.Result.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func workflowCommand(c *comment.Comment) string {
+	switch comment.ParseSeverity(c.Result.Severity) {
+	case comment.SeverityError:
+		return "error"
+	case comment.SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeWorkflowData escapes the message of a workflow command.
+// ref: https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+func escapeWorkflowData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeWorkflowProperty escapes a property value of a workflow command.
+func escapeWorkflowProperty(s string) string {
+	s = escapeWorkflowData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
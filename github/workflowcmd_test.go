@@ -0,0 +1,58 @@
+package github
+
+import (
+	"bytes"
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/comment"
+	"testing"
+)
+
+func TestEscapeWorkflowData(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain message", "plain message"},
+		{"100% done", "100%25 done"},
+		{"line1\nline2\r\n", "line1%0Aline2%0D%0A"},
+		{"a:b,c", "a:b,c"},
+	}
+	for _, tt := range tests {
+		if got := escapeWorkflowData(tt.in); got != tt.want {
+			t.Errorf("escapeWorkflowData(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeWorkflowProperty(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"src/A.java", "src/A.java"},
+		{"C:\\src\\A.java", "C%3A\\src\\A.java"},
+		{"a,b", "a%2Cb"},
+		{"50%\n", "50%25%0A"},
+	}
+	for _, tt := range tests {
+		if got := escapeWorkflowProperty(tt.in); got != tt.want {
+			t.Errorf("escapeWorkflowProperty(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReportAsWorkflowCommands(t *testing.T) {
+	comments := []*comment.Comment{
+		{Result: &checkstylexml.CheckStyleErrorFormat{File: "A.java", Line: 3, Column: 5, Severity: "error", Source: "a,b", Message: "bad\nthing"}},
+		{Result: &checkstylexml.CheckStyleErrorFormat{File: "B.java", Line: 1, EndLine: 4, Severity: "info", Message: "note"}},
+	}
+	var buf bytes.Buffer
+	if err := reportAsWorkflowCommands(&buf, comments); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=A.java,line=3,endLine=3,col=5,title=a%2Cb::bad%0Athing\n" +
+		"::notice file=B.java,line=1,endLine=4::note\n"
+	if got := buf.String(); got != want {
+		t.Errorf("reportAsWorkflowCommands() =\n%s\nwant\n%s", got, want)
+	}
+}
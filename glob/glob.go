@@ -0,0 +1,120 @@
+// Package glob provides path matching with "**" support.
+//
+// Patterns use the syntax of filepath.Match and additionally accept "**" as
+// a path segment which matches zero or more directories, e.g.
+// "**/build/reports/checkstyle/*.xml".
+package glob
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const doubleStar = "**"
+
+// Match reports whether a slash separated path matches pattern.
+func Match(pattern, name string) bool {
+	return matchSegments(split(pattern), split(name))
+}
+
+// HasMeta reports whether pattern contains any of the magic characters
+// recognized by Match.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Expand returns the names of all files matching pattern. A pattern without
+// magic characters is returned as is, even if the file does not exist, so that
+// the caller reports a proper error when opening it.
+func Expand(pattern string) ([]string, error) {
+	if !HasMeta(pattern) {
+		return []string{pattern}, nil
+	}
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if !strings.Contains(pattern, doubleStar) {
+		return filepath.Glob(filepath.FromSlash(pattern))
+	}
+	root := staticPrefix(pattern)
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if Match(pattern, filepath.ToSlash(p)) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// staticPrefix returns the leading directories of pattern which do not
+// contain magic characters.
+func staticPrefix(pattern string) string {
+	segs := split(pattern)
+	var prefix []string
+	for _, s := range segs[:len(segs)-1] {
+		if HasMeta(s) {
+			break
+		}
+		prefix = append(prefix, s)
+	}
+	if len(prefix) == 0 {
+		if strings.HasPrefix(pattern, "/") {
+			return "/"
+		}
+		return "."
+	}
+	root := strings.Join(prefix, "/")
+	if strings.HasPrefix(pattern, "/") {
+		root = "/" + root
+	}
+	return root
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == doubleStar {
+			// "**" matches zero or more segments.
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func split(p string) []string {
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if p == "" || p == "." {
+		return nil
+	}
+	var segs []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" && s != "." {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
@@ -0,0 +1,79 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"**/build/reports/checkstyle/*.xml", "build/reports/checkstyle/main.xml", true},
+		{"**/build/reports/checkstyle/*.xml", "app/core/build/reports/checkstyle/main.xml", true},
+		{"**/build/reports/checkstyle/*.xml", "app/build/reports/pmd/main.xml", false},
+		{"app/**/main.xml", "app/main.xml", true},
+		{"app/**/main.xml", "app/core/build/main.xml", true},
+		{"app/**/main.xml", "lib/core/main.xml", false},
+		{"src/main/**", "src/main/java/A.java", true},
+		{"src/main/**", "src/main", true},
+		{"src/main/**", "src/test/java/A.java", false},
+		{"**", "a/b/c", true},
+		{"*.java", "A.java", true},
+		{"*.java", "src/A.java", false},
+		{"src/?.java", "src/A.java", true},
+		{"src/[AB].java", "src/C.java", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{
+		"build/reports/checkstyle/main.xml",
+		"app/build/reports/checkstyle/main.xml",
+		"app/build/reports/checkstyle/test.xml",
+		"app/build/reports/pmd/main.xml",
+		".git/build/reports/checkstyle/main.xml",
+	} {
+		p = filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Expand(filepath.Join(dir, "**/build/reports/checkstyle/*.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		got[i], _ = filepath.Rel(dir, got[i])
+		got[i] = filepath.ToSlash(got[i])
+	}
+	sort.Strings(got)
+	want := []string{
+		"app/build/reports/checkstyle/main.xml",
+		"app/build/reports/checkstyle/test.xml",
+		"build/reports/checkstyle/main.xml",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+
+	literal := filepath.Join(dir, "missing.xml")
+	if got, err := Expand(literal); err != nil || !reflect.DeepEqual(got, []string{literal}) {
+		t.Errorf("Expand(%q) = %v, %v, want the pattern itself", literal, got, err)
+	}
+}
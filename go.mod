@@ -4,8 +4,8 @@ go 1.23
 
 require (
 	github.com/google/go-github/v64 v64.0.0
-	github.com/google/uuid v1.6.0
 	golang.org/x/oauth2 v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/google/go-querystring v1.1.0 // indirect
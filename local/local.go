@@ -0,0 +1,103 @@
+// Package local provides diff and comment services which work without GitHub,
+// so that violations can be checked before pushing.
+package local
+
+import (
+	"checkstyle-review/comment"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// GitDiff is a DiffService which runs `git diff <base>...HEAD`.
+type GitDiff struct {
+	Base string
+}
+
+// Diff returns changes of HEAD since it diverged from Base. Options which
+// user configuration could change, e.g. color.ui=always or diff.noprefix,
+// are set explicitly, so that the output can be parsed with Strip.
+func (g *GitDiff) Diff(ctx context.Context) ([]byte, error) {
+	b, err := exec.CommandContext(ctx, "git", "diff", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/", "--find-renames", g.Base+"...HEAD").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to run git diff: %s\n%w", exitErr.Stderr, err)
+		}
+		return nil, fmt.Errorf("failed to run git diff: %w", err)
+	}
+	return b, nil
+}
+
+// Strip returns 1 as a strip of git diff.
+func (g *GitDiff) Strip() int {
+	return 1
+}
+
+// DiffFile is a DiffService which reads a unified diff in git format from a
+// file.
+type DiffFile struct {
+	Path string
+}
+
+// Diff returns the content of the diff file.
+func (d *DiffFile) Diff(context.Context) ([]byte, error) {
+	return os.ReadFile(d.Path)
+}
+
+// Strip returns 1 as a strip of git diff.
+func (d *DiffFile) Strip() int {
+	return 1
+}
+
+// Printer is a CommentService which prints comments in compiler style
+// `file:line:col: severity: message [rule]`, which editors can jump to.
+type Printer struct {
+	W io.Writer
+}
+
+// Post prints comments.
+func (p *Printer) Post(_ context.Context, comments []*comment.Comment) error {
+	cwd, _ := os.Getwd()
+	for _, c := range comments {
+		if _, err := fmt.Fprintln(p.W, format(c, cwd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func format(c *comment.Comment, cwd string) string {
+	path := c.Result.File
+	if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsAbs(path) {
+		path = rel
+	}
+	s := fmt.Sprintf("%s:%d:", path, c.Result.Line)
+	if c.Result.Column > 0 {
+		s += fmt.Sprintf("%d:", c.Result.Column)
+	}
+	s += fmt.Sprintf(" %s: %s", severity(c), c.Result.Message)
+	if c.Result.Source != "" {
+		s += fmt.Sprintf(" [%s]", c.Result.Source)
+	}
+	return s
+}
+
+func severity(c *comment.Comment) string {
+	switch comment.ParseSeverity(c.Result.Severity) {
+	case comment.SeverityError:
+		return "error"
+	case comment.SeverityWarning:
+		return "warning"
+	case comment.SeverityInfo:
+		return "info"
+	default:
+		if c.Result.Severity != "" {
+			return c.Result.Severity
+		}
+		return "error"
+	}
+}
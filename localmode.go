@@ -0,0 +1,28 @@
+package main
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/local"
+	"checkstyle-review/runner"
+	"context"
+	"errors"
+	"os"
+)
+
+// runLocal runs the local mode, which filters violations by a local diff and
+// prints them in compiler style. It needs neither a GitHub token nor
+// GITHUB_EVENT_PATH.
+//
+//	checkstyle-review local -base origin/main -xmlPath build/reports/checkstyle/main.xml
+func runLocal(ctx context.Context, errorMap map[string][]*checkstylexml.CheckStyleErrorFormat, opts runner.Options) error {
+	var ds runner.DiffService
+	switch {
+	case opt.diffFile != "":
+		ds = &local.DiffFile{Path: opt.diffFile}
+	case opt.base != "":
+		ds = &local.GitDiff{Base: opt.base}
+	default:
+		return errors.New("local mode needs -base or -diff-file")
+	}
+	return runner.Run(ctx, ds, &local.Printer{W: os.Stdout}, errorMap, opts)
+}
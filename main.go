@@ -2,81 +2,335 @@ package main
 
 import (
 	"checkstyle-review/checkstylexml"
+	"checkstyle-review/comment"
 	"checkstyle-review/env"
+	"checkstyle-review/fingerprint"
 	"checkstyle-review/github"
+	"checkstyle-review/github/util"
+	"checkstyle-review/glob"
+	"checkstyle-review/pathutil"
+	"checkstyle-review/pmdxml"
 	"checkstyle-review/runner"
+	"checkstyle-review/sarif"
+	"checkstyle-review/spotbugsxml"
 	"context"
 	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	githubservice "github.com/google/go-github/v64/github"
-	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
 type option struct {
-	path string
+	paths        stringList
+	basePaths    stringList
+	format       string
+	reporter     string
+	filterMode   string
+	minSeverity  string
+	failLevel    string
+	reviewEvent  string
+	overflow     string
+	reviewDelay  time.Duration
+	maxComments  int
+	retryBudget  time.Duration
+	resolveFixed bool
+	replyFixed   bool
+	summary      bool
+	dryRun       bool
+	dryRunFormat string
+
+	// Set from the configuration file only.
+	reports      []reportSpec
+	baseReports  []reportSpec
+	toolName     string
+	ignoreRules  []string
+	includePaths []string
+	excludePaths []string
+	suppressions []*runner.Suppression
+
+	baseline string
+
+	// command is the subcommand: "local", "baseline" or empty.
+	command  string
+	base     string
+	diffFile string
+	prune    bool
 }
 
+// Subcommands.
+const (
+	commandLocal    = "local"
+	commandBaseline = "baseline"
+)
+
 var opt = &option{}
 
+// stringList is a flag.Value which collects repeated flags.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 func init() {
-	flag.StringVar(&opt.path, "xmlPath", "", "checkstyle xml doc path")
+	flag.Var(&opt.paths, "xmlPath", "report path or glob pattern such as **/build/reports/checkstyle/*.xml (repeatable)")
+	flag.Var(&opt.basePaths, "base-xmlPath", "report path or glob pattern of the base branch. Only violations which are not in it are reported (repeatable)")
+	flag.StringVar(&opt.format, "format", "checkstyle", "report format [checkstyle,sarif,pmd,spotbugs]")
+	flag.StringVar(&opt.reporter, "reporter", "github-pr-review", "reporter [github-pr-review,github-check]")
+	flag.StringVar(&opt.filterMode, "filter-mode", "diff_context", "filter mode [added,diff_context,file,nofilter]")
+	flag.StringVar(&opt.minSeverity, "min-severity", "none", "drop violations below the severity [none,info,warning,error]")
+	flag.StringVar(&opt.failLevel, "fail-level", "none", "exit with non-zero code when a violation in the diff is at or above the severity [none,info,warning,error]")
+	flag.StringVar(&opt.reviewEvent, "review-event", github.ReviewEventComment, "review event [COMMENT,REQUEST_CHANGES,APPROVE]. REQUEST_CHANGES and APPROVE request changes on error level violations")
+	flag.StringVar(&opt.overflow, "overflow", "summary", "how to post comments over 30 per review [summary,reviews]")
+	flag.DurationVar(&opt.reviewDelay, "review-delay", 5*time.Second, "delay between reviews posted with -overflow=reviews")
+	flag.IntVar(&opt.maxComments, "max-comments", 0, "maximum number of inline comments. 0 means no limit")
+	flag.DurationVar(&opt.retryBudget, "retry-budget", 2*time.Minute, "total time to wait for retries of rate limited GitHub API requests, shared by all requests of a run. 0 disables retries")
+	flag.BoolVar(&opt.summary, "summary-comment", false, "keep a single pull request comment with violation statistics up to date")
+	flag.BoolVar(&opt.dryRun, "dry-run", false, "print requests which would write to GitHub instead of sending them")
+	flag.StringVar(&opt.dryRunFormat, "dry-run-format", "text", "output format of -dry-run [text,json]")
+	flag.StringVar(&opt.base, "base", "", "local mode: base ref to diff against with `git diff <base>...HEAD`")
+	flag.StringVar(&opt.diffFile, "diff-file", "", "local mode: unified diff file to use instead of git diff")
+	flag.StringVar(&opt.baseline, "baseline", "", "baseline file of pre-existing violations which are not reported (default .checkstyle-baseline.json in the repository root if it exists)")
+	flag.BoolVar(&opt.prune, "prune", false, "baseline subcommand: remove entries of violations which are not in the report anymore")
+	flag.BoolVar(&opt.resolveFixed, "resolve-fixed", false, "resolve review threads whose violation has been fixed")
+	flag.BoolVar(&opt.replyFixed, "reply-fixed", false, `reply "Fixed in <sha>" before resolving a review thread (requires -resolve-fixed)`)
 }
 
 func main() {
-	flag.Parse()
-	// Assume fixed relative path and open main.xml
-	fmt.Printf("Running the checkstyke pre review tool\n")
-	open, err := os.Open(opt.path)
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == commandLocal || args[0] == commandBaseline) {
+		opt.command = args[0]
+		args = args[1:]
+	}
+	// flag.CommandLine exits on parse errors.
+	_ = flag.CommandLine.Parse(args)
+	fmt.Fprintf(os.Stderr, "Running the checkstyke pre review tool\n")
+	if err := loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	inputs, closeInputs, err := openReports(opt.reports)
 	if err != nil {
-		fmt.Printf("open file error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "report error: %v\n", err)
 		os.Exit(1)
 	}
-	if err := run(open); err != nil {
-		fmt.Printf("checkstyle review error: %v\n", err)
+	defer closeInputs()
+	var baseInputs []reportInput
+	if len(opt.baseReports) > 0 {
+		var closeBaseInputs func()
+		baseInputs, closeBaseInputs, err = openReports(opt.baseReports)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "base report error: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeBaseInputs()
+	}
+	if err := run(inputs, baseInputs); err != nil {
+		fmt.Fprintf(os.Stderr, "checkstyle review error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(inputCheckStyle io.Reader) error {
-	ctx := context.Background()
-	checkStyleParser := &checkstylexml.CheckStyleXML{}
+// stdinPath is the report path which reads the report from stdin.
+const stdinPath = "-"
 
-	parseResult, err := checkStyleParser.Parse(inputCheckStyle)
+// reportSpec represents a report path or glob pattern with its format.
+type reportSpec struct {
+	path   string
+	format string
+}
 
-	var errorMap = make(map[string][]*checkstylexml.CheckStyleErrorFormat)
-	for _, fileResult := range parseResult.Files {
-		errorMap[fileResult.Name] = make([]*checkstylexml.CheckStyleErrorFormat, 0)
-		for _, errorFormat := range fileResult.Errors {
-			k, _ := uuid.NewRandom()
-			newFormat := checkstylexml.CheckStyleErrorFormat{
-				ErrKey:   k,
-				File:     fileResult.Name,
-				Column:   errorFormat.Column,
-				Line:     errorFormat.Line,
-				Message:  errorFormat.Message,
-				Severity: errorFormat.Severity,
-				Source:   errorFormat.Source,
+// reportInput represents an opened report with its format.
+type reportInput struct {
+	r      io.Reader
+	format string
+}
+
+// openReports expands report paths and opens them. The returned function
+// closes the opened files.
+func openReports(specs []reportSpec) ([]reportInput, func(), error) {
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	reports, err := reportPaths(specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs := make([]reportInput, 0, len(reports))
+	for _, report := range reports {
+		if report.path == stdinPath {
+			// Reports are decoded as a stream, so piped input is never
+			// buffered as a whole.
+			inputs = append(inputs, reportInput{r: os.Stdin, format: report.format})
+			continue
+		}
+		f, err := os.Open(report.path)
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		inputs = append(inputs, reportInput{r: f, format: report.format})
+	}
+	return inputs, closeFiles, nil
+}
+
+// reportPaths expands glob patterns of report paths. It reads from stdin if
+// no path is given and the input is piped.
+func reportPaths(specs []reportSpec) ([]reportSpec, error) {
+	if len(specs) == 0 {
+		if !isStdinPiped() {
+			return nil, errors.New("-xmlPath is not set and stdin is not piped")
+		}
+		return []reportSpec{{path: stdinPath, format: opt.format}}, nil
+	}
+	var reports []reportSpec
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		if spec.path == stdinPath {
+			if seen[stdinPath] {
+				return nil, errors.New("stdin can be read only once")
+			}
+			seen[stdinPath] = true
+			reports = append(reports, spec)
+			continue
+		}
+		matches, err := glob.Expand(spec.path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report pattern %q: %w", spec.path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no report matches %q", spec.path)
+		}
+		for _, m := range matches {
+			// The same report may match several patterns.
+			if !seen[m] {
+				seen[m] = true
+				reports = append(reports, reportSpec{path: m, format: spec.format})
 			}
+		}
+	}
+	return reports, nil
+}
+
+func isStdinPiped() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
+}
+
+// run parses all reports and posts their violations as a single review. If
+// base reports are given, only violations which are not in them are posted.
+func run(inputs, baseInputs []reportInput) error {
+	ctx := context.Background()
+	filterMode, err := runner.ParseFilterMode(opt.filterMode)
+	if err != nil {
+		return err
+	}
+	minSeverity, err := comment.ParseLevel(opt.minSeverity)
+	if err != nil {
+		return err
+	}
+	failLevel, err := comment.ParseLevel(opt.failLevel)
+	if err != nil {
+		return err
+	}
+	reviewEvent, err := github.ParseReviewEvent(opt.reviewEvent)
+	if err != nil {
+		return err
+	}
+	if opt.overflow != "summary" && opt.overflow != "reviews" {
+		return fmt.Errorf("unknown overflow mode: %q", opt.overflow)
+	}
+	if opt.dryRunFormat != "text" && opt.dryRunFormat != "json" {
+		return fmt.Errorf("unknown dry-run format: %q", opt.dryRunFormat)
+	}
+
+	parseResult, err := parseReports(inputs)
+	if err != nil {
+		return err
+	}
 
-			errorMap[fileResult.Name] = append(errorMap[fileResult.Name], &newFormat)
+	rootPath, err := util.GetGitRoot()
+	if err != nil {
+		return err
+	}
+	var errorMap = make(map[string][]*checkstylexml.CheckStyleErrorFormat)
+	// Identical violations are numbered in order of their position.
+	sort.SliceStable(parseResult, func(i, j int) bool {
+		if parseResult[i].Line != parseResult[j].Line {
+			return parseResult[i].Line < parseResult[j].Line
+		}
+		return parseResult[i].Column < parseResult[j].Column
+	})
+	fp := fingerprint.NewFingerprinter(os.Stderr)
+	for _, errorFormat := range parseResult {
+		relPath := pathutil.NormalizePath(errorFormat.File, rootPath, "")
+		errorFormat.ErrKey, errorFormat.BaselineKey = fp.Fingerprint(errorFormat.File, relPath, errorFormat.Source, errorFormat.Message, errorFormat.Line)
+		errorMap[errorFormat.File] = append(errorMap[errorFormat.File], errorFormat)
+	}
 
+	runOpts := runner.Options{
+		FilterMode:   filterMode,
+		MinSeverity:  minSeverity,
+		FailLevel:    failLevel,
+		ToolName:     opt.toolName,
+		RootDir:      rootPath,
+		IgnoreRules:  opt.ignoreRules,
+		IncludePaths: opt.includePaths,
+		ExcludePaths: opt.excludePaths,
+		Suppressions: opt.suppressions,
+	}
+	if opt.command == commandBaseline {
+		return writeBaseline(errorMap, rootPath)
+	}
+	if baseInputs != nil {
+		baseResult, err := parseReports(baseInputs)
+		if err != nil {
+			return fmt.Errorf("base report: %w", err)
 		}
+		runOpts.BaseResults = make(map[string][]*checkstylexml.CheckStyleErrorFormat)
+		for _, errorFormat := range baseResult {
+			runOpts.BaseResults[errorFormat.File] = append(runOpts.BaseResults[errorFormat.File], errorFormat)
+		}
+	}
+	if runOpts.Baseline, err = loadBaseline(rootPath); err != nil {
+		return err
+	}
+	if opt.command == commandLocal {
+		return runLocal(ctx, errorMap, runOpts)
 	}
 
+	summaryFile, err := jobSummary()
 	if err != nil {
 		return err
 	}
+	if summaryFile != nil {
+		defer summaryFile.Close()
+	}
 
 	var ds *github.PullRequest
 
-	gs, isPR, err := githubService(ctx)
+	gs, cs, isPR, err := githubService(ctx)
 	if err != nil {
 		return err
 	}
@@ -85,39 +339,134 @@ func run(inputCheckStyle io.Reader) error {
 		if err != nil {
 			return err
 		}
+		if summaryFile != nil {
+			// There is no diff to filter by, so every selected violation is
+			// listed.
+			comments := make([]*comment.Comment, 0, len(parseResult))
+			for _, results := range runner.Select(errorMap, runOpts) {
+				for _, res := range results {
+					comments = append(comments, &comment.Comment{Result: res, ToolName: opt.toolName})
+				}
+			}
+			return github.WriteJobSummary(summaryFile, comments, jobSummaryBaseURL())
+		}
 		return nil
 	}
 	ds = gs
+	ds.ResolveFixed = opt.resolveFixed
+	ds.ReplyOnResolve = opt.replyFixed
+	ds.ReviewEvent = reviewEvent
+	ds.SplitReviews = opt.overflow == "reviews"
+	ds.ReviewDelay = opt.reviewDelay
+	ds.MaxComments = opt.maxComments
+	ds.SummaryComment = opt.summary
+
+	runOpts.RunURL = env.GitHubRunURL()
+	runOpts.ThreadResolver = ds
+	runOpts.SummaryService = ds
+	if summaryFile != nil {
+		runOpts.JobSummary = &github.JobSummary{W: summaryFile, BaseURL: jobSummaryBaseURL()}
+	}
+
+	fmt.Fprintf(os.Stderr, "Running checkstyle: %d\n", len(errorMap))
+	return runner.Run(ctx, ds, cs, errorMap, runOpts)
+
+}
+
+// parseReports parses reports with the parser of their format.
+func parseReports(inputs []reportInput) ([]*checkstylexml.CheckStyleErrorFormat, error) {
+	var parseResult []*checkstylexml.CheckStyleErrorFormat
+	for _, input := range inputs {
+		checkStyleParser, err := newParser(input.format)
+		if err != nil {
+			return nil, err
+		}
+		errs, err := checkStyleParser.ParseErrors(input.r)
+		if err != nil {
+			return nil, err
+		}
+		parseResult = append(parseResult, errs...)
+	}
+	return parseResult, nil
+}
 
-	fmt.Printf("Running checkstyle: %d\n", len(errorMap))
-	return runner.Run(ctx, ds, errorMap)
+// jobSummary opens the job summary file of GitHub Actions for appending. It
+// returns nil if GITHUB_STEP_SUMMARY is not set.
+func jobSummary() (*os.File, error) {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job summary: %w", err)
+	}
+	return f, nil
+}
 
+// jobSummaryBaseURL returns the blob URL of the commit under review.
+func jobSummaryBaseURL() string {
+	sha := os.Getenv("GITHUB_SHA")
+	if info, _, err := env.GetBuildInfo(); err == nil && info.SHA != "" {
+		sha = info.SHA
+	}
+	return env.GitHubBlobBaseURL(sha)
+}
+
+func newParser(format string) (checkstylexml.Parser, error) {
+	switch format {
+	case "checkstyle":
+		return &checkstylexml.CheckStyleXML{}, nil
+	case "sarif":
+		return &sarif.SARIF{}, nil
+	case "pmd":
+		return &pmdxml.PMDXML{}, nil
+	case "spotbugs":
+		return &spotbugsxml.SpotBugsXML{}, nil
+	default:
+		return nil, fmt.Errorf("unknown report format: %q", format)
+	}
 }
 
-func githubService(ctx context.Context) (gs *github.PullRequest, isPR bool, err error) {
+func githubService(ctx context.Context) (gs *github.PullRequest, cs runner.CommentService, isPR bool, err error) {
 	g, client, err := githubBuildInfoWithClient(ctx)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 	if g.PullRequest == 0 {
 
 		if g.Branch == "" && g.SHA == "" {
-			return nil, false, nil
+			return nil, nil, false, nil
 		}
 
 		prID, err := getPullRequestIDByBranchOrCommit(ctx, client, g)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
-			return nil, false, nil
+			return nil, nil, false, nil
 		}
 		g.PullRequest = prID
 	}
 
+	var dryRun *github.DryRun
+	if opt.dryRun {
+		dryRun = &github.DryRun{W: os.Stdout, JSON: opt.dryRunFormat == "json"}
+	}
 	gs, err = github.NewGitHubPullRequest(client, g.Owner, g.Repo, g.PullRequest, g.SHA)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
+	}
+	gs.DryRun = dryRun
+	switch opt.reporter {
+	case "github-pr-review":
+		cs = gs
+	case "github-check":
+		check := github.NewGitHubCheck(client, g.Owner, g.Repo, g.SHA)
+		check.DryRun = dryRun
+		cs = check
+	default:
+		return nil, nil, false, fmt.Errorf("unknown reporter: %q", opt.reporter)
 	}
-	return gs, true, nil
+	return gs, cs, true, nil
 }
 
 func githubBuildInfoWithClient(ctx context.Context) (*env.BuildInfo, *githubservice.Client, error) {
@@ -218,5 +567,5 @@ func newHTTPClient() *http.Client {
 		Proxy:           http.ProxyFromEnvironment,
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
 	}
-	return &http.Client{Transport: tr}
+	return &http.Client{Transport: github.NewRetryTransport(tr, opt.retryBudget)}
 }
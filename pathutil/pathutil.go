@@ -0,0 +1,47 @@
+// Package pathutil normalizes paths of violations and diffs, so that they can
+// be compared with each other.
+package pathutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// NormalizePath return normalized path with workdir and relative path to
+// project.
+func NormalizePath(path, workdir, projectRelPath string) string {
+	path = filepath.Clean(path)
+	if path == "." {
+		return ""
+	}
+	// Convert absolute path to relative path only if the path is in current
+	// directory.
+	if filepath.IsAbs(path) && workdir != "" && contains(path, workdir) {
+		relPath, err := filepath.Rel(workdir, path)
+		if err == nil {
+			path = relPath
+		}
+	}
+	if !filepath.IsAbs(path) && projectRelPath != "" {
+		path = filepath.Join(projectRelPath, path)
+	}
+	return filepath.ToSlash(path)
+}
+
+func contains(path, base string) bool {
+	ps := splitPathList(path)
+	bs := splitPathList(base)
+	if len(ps) < len(bs) {
+		return false
+	}
+	for i := range bs {
+		if bs[i] != ps[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPathList(path string) []string {
+	return strings.Split(filepath.ToSlash(path), "/")
+}
@@ -0,0 +1,86 @@
+package pmdxml
+
+import (
+	"checkstyle-review/checkstylexml"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// PMDXML is a parser of PMD XML report.
+type PMDXML struct{}
+
+func (*PMDXML) Parse(r io.Reader) (*PMDResult, error) {
+	var result = new(PMDResult)
+	err := xml.NewDecoder(r).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseErrors parses PMD XML report and returns its violations.
+func (p *PMDXML) ParseErrors(r io.Reader) ([]*checkstylexml.CheckStyleErrorFormat, error) {
+	result, err := p.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	var errs []*checkstylexml.CheckStyleErrorFormat
+	for _, file := range result.Files {
+		for _, v := range file.Violations {
+			errs = append(errs, &checkstylexml.CheckStyleErrorFormat{
+				File:      file.Name,
+				Column:    v.BeginColumn,
+				Line:      v.BeginLine,
+				EndLine:   v.EndLine,
+				EndColumn: v.EndColumn,
+				Message:   strings.TrimSpace(v.Message),
+				Severity:  severity(v.Priority),
+				Source:    v.Rule,
+			})
+		}
+	}
+	return errs, nil
+}
+
+// PMDResult represents PMD XML result.
+// <?xml version="1.0" encoding="UTF-8"?><pmd version="6.55.0"><file ...></file>...</pmd>
+//
+// References:
+//   - https://pmd.github.io/pmd/pmd_userdocs_report_formats.html#xml
+type PMDResult struct {
+	XMLName xml.Name   `xml:"pmd"`
+	Version string     `xml:"version,attr"`
+	Files   []*PMDFile `xml:"file,omitempty"`
+}
+
+// PMDFile represents <file name="fname"><violation ...>...</violation>...</file>
+type PMDFile struct {
+	Name       string          `xml:"name,attr"`
+	Violations []*PMDViolation `xml:"violation"`
+}
+
+// PMDViolation represents <violation beginline="1" endline="2" begincolumn="3" endcolumn="4" rule="r" ruleset="rs" priority="3">msg</violation>
+type PMDViolation struct {
+	BeginLine   int    `xml:"beginline,attr"`
+	EndLine     int    `xml:"endline,attr"`
+	BeginColumn int    `xml:"begincolumn,attr"`
+	EndColumn   int    `xml:"endcolumn,attr"`
+	Rule        string `xml:"rule,attr"`
+	RuleSet     string `xml:"ruleset,attr"`
+	Priority    int    `xml:"priority,attr"`
+	Message     string `xml:",chardata"`
+}
+
+// severity maps PMD priority (1 is the highest, 5 is the lowest) to checkstyle
+// severity.
+func severity(priority int) string {
+	switch priority {
+	case 1, 2:
+		return "error"
+	case 3:
+		return "warning"
+	default:
+		return "info"
+	}
+}
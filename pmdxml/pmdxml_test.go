@@ -0,0 +1,43 @@
+package pmdxml
+
+import (
+	"checkstyle-review/checkstylexml"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	f, err := os.Open("testdata/pmd.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := (&PMDXML{}).ParseErrors(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*checkstylexml.CheckStyleErrorFormat{
+		{
+			File: "/work/src/main/java/com/example/Foo.java", Line: 1, Column: 1, EndLine: 400, EndColumn: 2,
+			Message: "Possible God Class", Severity: "error", Source: "GodClass",
+		},
+		{
+			File: "/work/src/main/java/com/example/Foo.java", Line: 12, Column: 9, EndLine: 12, EndColumn: 21,
+			Message: "Avoid unused local variables such as 'x'.", Severity: "warning", Source: "UnusedLocalVariable",
+		},
+		{
+			File: "/work/src/main/java/com/example/Bar.java", Line: 5, Column: 3, EndLine: 5, EndColumn: 3,
+			Message: "Useless parentheses.", Severity: "info", Source: "UselessParentheses",
+		},
+	}
+	if len(got) != len(want) {
+		t.Errorf("ParseErrors() returned %d violations, want %d", len(got), len(want))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("got[%d] = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/diff"
+	"checkstyle-review/pathutil"
+	"fmt"
+	"os"
+)
+
+// delta represents the difference between a base report and a head report.
+type delta struct {
+	// added are violations of the head report which are not in the base
+	// report.
+	added map[*checkstylexml.CheckStyleErrorFormat]bool
+	// fixed are violations of the base report which are not in the head
+	// report, keyed by the normalized path of the new file.
+	fixed map[string][]*checkstylexml.CheckStyleErrorFormat
+}
+
+// devNull is the path of the missing side of added and deleted files.
+const devNull = "/dev/null"
+
+// deltaKey identifies violations which are the same regardless of their line.
+type deltaKey struct {
+	path    string
+	source  string
+	message string
+}
+
+// compareReports matches violations of the head report against the base
+// report. Lines of the base report are mapped to the new file through the
+// hunks of the diff first. Violations left over are then matched regardless of
+// their line, so that code moved within a file is not reported as new.
+func compareReports(head, base map[string][]*checkstylexml.CheckStyleErrorFormat, fileDiffs []*diff.FileDiff, strip int) *delta {
+	cwd, _ := os.Getwd()
+	renames := make(map[string]string)
+	hunks := make(map[string][]*diff.Hunk)
+	for _, file := range fileDiffs {
+		pathOld := normalizeDiffPath(file.PathOld, strip)
+		hunks[pathOld] = file.Hunks
+		// Violations of deleted files keep their old path.
+		if file.PathNew != devNull {
+			renames[pathOld] = normalizeDiffPath(file.PathNew, strip)
+		}
+	}
+
+	// baseLine is a violation of the base report with its line in the new
+	// file, which is 0 if the line has been deleted.
+	type baseLine struct {
+		res     *checkstylexml.CheckStyleErrorFormat
+		newLine int
+	}
+	pending := make(map[deltaKey][]*baseLine)
+	for fileName, results := range base {
+		pathOld := pathutil.NormalizePath(fileName, cwd, "")
+		pathNew := pathOld
+		if p, ok := renames[pathOld]; ok {
+			pathNew = p
+		}
+		for _, res := range results {
+			k := deltaKey{path: pathNew, source: res.Source, message: res.Message}
+			newLine, _ := mapLine(hunks[pathOld], res.Line)
+			pending[k] = append(pending[k], &baseLine{res: res, newLine: newLine})
+		}
+	}
+
+	d := &delta{
+		added: make(map[*checkstylexml.CheckStyleErrorFormat]bool),
+		fixed: make(map[string][]*checkstylexml.CheckStyleErrorFormat),
+	}
+	match := func(k deltaKey, pred func(*baseLine) bool) bool {
+		for i, b := range pending[k] {
+			if pred(b) {
+				pending[k] = append(pending[k][:i], pending[k][i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	var unmatched []*checkstylexml.CheckStyleErrorFormat
+	for fileName, results := range head {
+		path := pathutil.NormalizePath(fileName, cwd, "")
+		for _, res := range results {
+			k := deltaKey{path: path, source: res.Source, message: res.Message}
+			if !match(k, func(b *baseLine) bool { return b.newLine == res.Line }) {
+				unmatched = append(unmatched, res)
+			}
+		}
+	}
+	for _, res := range unmatched {
+		k := deltaKey{path: pathutil.NormalizePath(res.File, cwd, ""), source: res.Source, message: res.Message}
+		if !match(k, func(*baseLine) bool { return true }) {
+			d.added[res] = true
+		}
+	}
+	for k, bs := range pending {
+		for _, b := range bs {
+			// Paths of the base report may differ from the head report.
+			fixed := *b.res
+			fixed.File = k.path
+			d.fixed[k.path] = append(d.fixed[k.path], &fixed)
+		}
+	}
+	return d
+}
+
+// mapLine maps a line number of the old file to the new file. It returns
+// false if the line has been deleted.
+func mapLine(hunks []*diff.Hunk, line int) (int, bool) {
+	offset := 0
+	for _, h := range hunks {
+		// A hunk with an empty range starts after the line it refers to.
+		startOld, startNew := h.StartLineOld, h.StartLineNew
+		if h.LineLengthOld == 0 {
+			startOld++
+		}
+		if h.LineLengthNew == 0 {
+			startNew++
+		}
+		if line < startOld {
+			break
+		}
+		if line < startOld+h.LineLengthOld {
+			for _, l := range h.Lines {
+				if l.LnumOld == line && l.Type == diff.LineUnchanged {
+					return l.LnumNew, true
+				}
+			}
+			return 0, false
+		}
+		offset = (startNew + h.LineLengthNew) - (startOld + h.LineLengthOld)
+	}
+	return line + offset, true
+}
+
+// filterAddedErrors returns violations added by the head report. Added
+// violations on lines of the diff are returned as filterErrors and the others
+// as outsideErrors.
+func filterAddedErrors(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, linesPerFile diffIndex, added map[*checkstylexml.CheckStyleErrorFormat]bool) (filterErrors, outsideErrors []*checkstylexml.CheckStyleErrorFormat) {
+	cwd, _ := os.Getwd()
+	filterErrors = make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	outsideErrors = make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	for fileName, checkStyleResult := range checkStyleResults {
+		lines := linesPerFile[pathutil.NormalizePath(fileName, cwd, "")]
+		for _, checkStyleErr := range checkStyleResult {
+			switch {
+			case !added[checkStyleErr]:
+			case lines[checkStyleErr.Line] != nil:
+				filterErrors = append(filterErrors, checkStyleErr)
+			default:
+				outsideErrors = append(outsideErrors, checkStyleErr)
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Errors added since the base report: %d\n", len(filterErrors)+len(outsideErrors))
+	return filterErrors, outsideErrors
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/diff"
+	"strings"
+	"testing"
+)
+
+// insertAndDelete inserts two lines after line 5 and deletes lines 10 and 11,
+// as produced by git diff -U0.
+const insertAndDelete = `diff --git a/f b/f
+--- a/f
++++ b/f
+@@ -5,0 +6,2 @@
++a
++b
+@@ -10,2 +11,0 @@
+-10
+-11
+`
+
+// modifyWithContext replaces line 10 with one line of context.
+const modifyWithContext = `diff --git a/f b/f
+--- a/f
++++ b/f
+@@ -9,3 +9,3 @@
+ 9
+-10
++x
+ 11
+`
+
+func parseHunks(t *testing.T, d string) []*diff.Hunk {
+	t.Helper()
+	fileDiffs, err := diff.ParseMultiFile(strings.NewReader(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileDiffs[0].Hunks
+}
+
+func TestMapLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		diff   string
+		line   int
+		want   int
+		wantOK bool
+	}{
+		{name: "before insertion", diff: insertAndDelete, line: 1, want: 1, wantOK: true},
+		{name: "line of empty old range", diff: insertAndDelete, line: 5, want: 5, wantOK: true},
+		{name: "after insertion", diff: insertAndDelete, line: 6, want: 8, wantOK: true},
+		{name: "before deletion", diff: insertAndDelete, line: 9, want: 11, wantOK: true},
+		{name: "deleted", diff: insertAndDelete, line: 10, wantOK: false},
+		{name: "last deleted", diff: insertAndDelete, line: 11, wantOK: false},
+		{name: "after deletion", diff: insertAndDelete, line: 12, want: 12, wantOK: true},
+		{name: "after all hunks", diff: insertAndDelete, line: 20, want: 20, wantOK: true},
+		{name: "context before change", diff: modifyWithContext, line: 9, want: 9, wantOK: true},
+		{name: "modified", diff: modifyWithContext, line: 10, wantOK: false},
+		{name: "context after change", diff: modifyWithContext, line: 11, want: 11, wantOK: true},
+		{name: "after hunk", diff: modifyWithContext, line: 15, want: 15, wantOK: true},
+		{name: "no hunks", line: 7, want: 7, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hunks []*diff.Hunk
+			if tt.diff != "" {
+				hunks = parseHunks(t, tt.diff)
+			}
+			got, ok := mapLine(hunks, tt.line)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("mapLine(%d) = %d, %v; want %d, %v", tt.line, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	moved := &checkstylexml.CheckStyleErrorFormat{File: "f", Line: 3, Source: "S", Message: "moved"}
+	shifted := &checkstylexml.CheckStyleErrorFormat{File: "f", Line: 8, Source: "S", Message: "shifted"}
+	added := &checkstylexml.CheckStyleErrorFormat{File: "f", Line: 6, Source: "S", Message: "added"}
+	head := map[string][]*checkstylexml.CheckStyleErrorFormat{"f": {moved, shifted, added}}
+	base := map[string][]*checkstylexml.CheckStyleErrorFormat{"f": {
+		{File: "f", Line: 15, Source: "S", Message: "moved"},
+		{File: "f", Line: 6, Source: "S", Message: "shifted"},
+		{File: "f", Line: 10, Source: "S", Message: "fixed"},
+	}}
+	fileDiffs, err := diff.ParseMultiFile(strings.NewReader(insertAndDelete))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := compareReports(head, base, fileDiffs, 1)
+
+	if len(d.added) != 1 || !d.added[added] {
+		t.Errorf("added = %v, want only %v", d.added, added)
+	}
+	fixed := d.fixed["f"]
+	if len(fixed) != 1 || fixed[0].Message != "fixed" {
+		t.Errorf("fixed = %v, want the violation of the deleted line", fixed)
+	}
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"checkstyle-review/diff"
+	"fmt"
+)
+
+// FilterMode represents a way to filter violations by the diff.
+type FilterMode int
+
+const (
+	// FilterModeDiffContext reports violations on added lines and on
+	// unchanged, contextual lines of the diff.
+	FilterModeDiffContext FilterMode = iota
+	// FilterModeAdded reports violations on added lines only.
+	FilterModeAdded
+	// FilterModeFile reports every violation in files which are part of the
+	// diff.
+	FilterModeFile
+	// FilterModeNoFilter reports every violation.
+	FilterModeNoFilter
+)
+
+var filterModeNames = map[FilterMode]string{
+	FilterModeDiffContext: "diff_context",
+	FilterModeAdded:       "added",
+	FilterModeFile:        "file",
+	FilterModeNoFilter:    "nofilter",
+}
+
+// String implements fmt.Stringer.
+func (m FilterMode) String() string {
+	return filterModeNames[m]
+}
+
+// ParseFilterMode parses a filter mode name.
+func ParseFilterMode(s string) (FilterMode, error) {
+	for mode, name := range filterModeNames {
+		if name == s {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown filter mode: %q", s)
+}
+
+// shouldReport returns true if a violation on a given line should be reported
+// in the mode. line is nil if the line is not part of the diff and inFile is
+// true if the file is part of the diff.
+func (m FilterMode) shouldReport(line *diff.Line, inFile bool) bool {
+	switch m {
+	case FilterModeAdded:
+		return line != nil && line.Type == diff.LineAdded
+	case FilterModeFile:
+		return inFile
+	case FilterModeNoFilter:
+		return true
+	default:
+		return line != nil
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"checkstyle-review/checkstylexml"
 	"checkstyle-review/comment"
 	"checkstyle-review/diff"
-	"checkstyle-review/github"
+	"checkstyle-review/pathutil"
 	"context"
 	"errors"
 	"fmt"
@@ -20,44 +20,232 @@ type DiffService interface {
 	Strip() int
 }
 
-var linesPerFile = make(map[string]map[int]*diff.Line)
+// CommentService is an interface which posts comments.
+type CommentService interface {
+	Post(context.Context, []*comment.Comment) error
+}
 
-func Run(ctx context.Context, diffService *github.PullRequest, checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat) error {
+// ThreadResolver is an interface which resolves review threads of violations
+// which are not among current violations anymore.
+type ThreadResolver interface {
+	ResolveFixedThreads(ctx context.Context, current []*checkstylexml.CheckStyleErrorFormat) error
+}
 
-	b, err := diffService.Diff(ctx)
+// SummaryService is an interface which posts the summary of a run.
+type SummaryService interface {
+	PostSummary(context.Context, *comment.Summary) error
+}
+
+// JobSummaryWriter is an interface which writes a report of comments to post,
+// e.g. as the job summary of a CI run.
+type JobSummaryWriter interface {
+	WriteJobSummary([]*comment.Comment) error
+}
+
+// Options represents options of Run.
+type Options struct {
+	// FilterMode decides which violations are reported.
+	FilterMode FilterMode
+	// MinSeverity drops violations below the severity before filtering.
+	// comment.SeverityUnknown keeps every violation.
+	MinSeverity comment.Severity
+	// FailLevel makes Run return ErrFailLevel when a violation in the diff is
+	// at or above the severity. comment.SeverityUnknown never fails.
+	FailLevel comment.Severity
+	// RunURL is a link to the CI run shown in the summary comment.
+	RunURL string
+	// JobSummary writes a report of reported violations if set.
+	JobSummary JobSummaryWriter
+	// ThreadResolver resolves review threads of fixed violations if set.
+	ThreadResolver ThreadResolver
+	// SummaryService posts the summary of the run if set.
+	SummaryService SummaryService
+	// ToolName is the name of the tool shown in comments. DefaultToolName is
+	// used if empty.
+	ToolName string
+	// IgnoreRules are sources of violations which are never reported.
+	IgnoreRules []string
+	// RootDir is the directory which IncludePaths, ExcludePaths and Paths of
+	// Suppressions are relative to. The working directory is used if empty.
+	RootDir string
+	// IncludePaths are glob patterns of paths whose violations are reported.
+	// Every path is included if empty.
+	IncludePaths []string
+	// ExcludePaths are glob patterns of paths whose violations are never
+	// reported. They win over IncludePaths.
+	ExcludePaths []string
+	// Suppressions drop matching violations before filtering by the diff.
+	Suppressions []*Suppression
+	// Baseline is a set of baseline keys of pre-existing violations which are
+	// never reported.
+	Baseline map[string]bool
+	// BaseResults are violations of a report of the base branch. If set,
+	// only violations which are not in it are reported, regardless of
+	// FilterMode, and the summary lists violations which have been fixed.
+	BaseResults map[string][]*checkstylexml.CheckStyleErrorFormat
+}
+
+// DefaultToolName is the tool name shown in comments by default.
+const DefaultToolName = "checkStyle"
+
+func (o *Options) toolName() string {
+	if o.ToolName == "" {
+		return DefaultToolName
+	}
+	return o.ToolName
+}
+
+// ErrFailLevel is returned by Run when violations in the diff are at or above
+// Options.FailLevel.
+var ErrFailLevel = errors.New("found violations at or above the fail level")
+
+// diffIndex maps normalized paths of files in a diff to their diff lines
+// keyed by line number of the new file.
+type diffIndex map[string]map[int]*diff.Line
+
+// Run filters violations by the diff of diffService and posts them with
+// commentService.
+func Run(ctx context.Context, diffService DiffService, commentService CommentService, checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, opts Options) error {
+
+	f, err := filterByDiff(ctx, diffService, checkStyleResults, opts)
 	if err != nil {
 		return err
 	}
-	fileDiffs, err := diff.ParseMultiFile(bytes.NewReader(b))
+	var errs []error
+	postComments := f.comments
+
+	if opts.JobSummary != nil {
+		if err := opts.JobSummary.WriteJobSummary(postComments); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write job summary: %w", err))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Posting comments: %d\n", len(postComments))
+	err = commentService.Post(ctx, postComments)
 	if err != nil {
 		return err
 	}
-	var errs []error
-	createDiffMappingDataStructures(fileDiffs)
-	fmt.Printf("lines per file: %v\n", linesPerFile)
-	filteredErrors := filterCheckStyleErrors(checkStyleResults)
-	fmt.Printf("Filtered errors: %d\n", len(filteredErrors))
+
+	if opts.ThreadResolver != nil {
+		// Compare against every violation rather than only filtered ones, so a
+		// violation which moved out of the diff is not reported as fixed.
+		var current []*checkstylexml.CheckStyleErrorFormat
+		for _, results := range checkStyleResults {
+			current = append(current, results...)
+		}
+		if err := opts.ThreadResolver.ResolveFixedThreads(ctx, current); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if opts.SummaryService != nil {
+		summary := &comment.Summary{
+			InDiff:     f.inDiff,
+			OutOfDiff:  outOfDiff(f.results, f.inDiff),
+			Suppressed: f.suppressed,
+			Fixed:      f.fixed,
+			RunURL:     opts.RunURL,
+		}
+		if err := opts.SummaryService.PostSummary(ctx, summary); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := f.failLevelError(opts.FailLevel); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// filtered represents violations filtered by the diff.
+type filtered struct {
+	// results are violations selected by Select.
+	results map[string][]*checkstylexml.CheckStyleErrorFormat
+	// inDiff are reported violations on lines of the diff.
+	inDiff []*checkstylexml.CheckStyleErrorFormat
+	// comments are all reported violations.
+	comments []*comment.Comment
+	// suppressed counts violations dropped by each suppression.
+	suppressed map[string]int
+	// fixed are violations of Options.BaseResults which are not in the head
+	// report anymore.
+	fixed []*checkstylexml.CheckStyleErrorFormat
+}
+
+func filterByDiff(ctx context.Context, diffService DiffService, checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, opts Options) (*filtered, error) {
+	b, err := diffService.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fileDiffs, err := diff.ParseMultiFile(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	linesPerFile := newDiffIndex(fileDiffs, diffService.Strip())
+	fmt.Fprintf(os.Stderr, "Files in diff: %d\n", len(linesPerFile))
+	results, suppressed := selectResults(checkStyleResults, opts)
+	for name, n := range suppressed {
+		fmt.Fprintf(os.Stderr, "Suppressed by %s: %d\n", name, n)
+	}
+	var filteredErrors, outsideErrors, fixed []*checkstylexml.CheckStyleErrorFormat
+	if opts.BaseResults != nil {
+		// Violations are compared before they are selected, so that a
+		// suppressed violation is not reported as fixed.
+		d := compareReports(checkStyleResults, opts.BaseResults, fileDiffs, diffService.Strip())
+		filteredErrors, outsideErrors = filterAddedErrors(results, linesPerFile, d.added)
+		for _, results := range Select(d.fixed, opts) {
+			fixed = append(fixed, results...)
+		}
+		fmt.Fprintf(os.Stderr, "Errors fixed since the base report: %d\n", len(fixed))
+	} else {
+		filteredErrors, outsideErrors = filterCheckStyleErrors(results, linesPerFile, opts.FilterMode)
+	}
+	fmt.Fprintf(os.Stderr, "Filtered errors: %d\n", len(filteredErrors))
+	fmt.Fprintf(os.Stderr, "Errors outside the diff: %d\n", len(outsideErrors))
 	postComments := make([]*comment.Comment, 0)
+	cwd, _ := os.Getwd()
 	for _, res := range filteredErrors {
 		newC := &comment.Comment{
 			Result:   res,
-			ToolName: "checkStyle",
+			ToolName: opts.toolName(),
+			EndLine:  hunkEndLine(linesPerFile[pathutil.NormalizePath(res.File, cwd, "")], res.Line, res.EndLine),
 		}
 		postComments = append(postComments, newC)
 	}
-
-	fmt.Printf("Posting comments: %d\n", len(postComments))
-	err = diffService.PostAsReviewComment(ctx, postComments)
-	if err != nil {
-		return err
+	for _, res := range outsideErrors {
+		newC := &comment.Comment{
+			Result:      res,
+			ToolName:    opts.toolName(),
+			OutsideDiff: true,
+		}
+		postComments = append(postComments, newC)
 	}
+	return &filtered{results: results, inDiff: filteredErrors, comments: postComments, suppressed: suppressed, fixed: fixed}, nil
+}
 
-	return errors.Join(errs...)
+// failLevelError returns ErrFailLevel if a violation in the diff is at or
+// above level.
+func (f *filtered) failLevelError(level comment.Severity) error {
+	if level == comment.SeverityUnknown {
+		return nil
+	}
+	failed := 0
+	for _, res := range f.inDiff {
+		if comment.ParseSeverity(res.Severity).AtLeast(level) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d", ErrFailLevel, failed)
+	}
+	return nil
 }
 
-func createDiffMappingDataStructures(fileDiffs []*diff.FileDiff) {
+func newDiffIndex(fileDiffs []*diff.FileDiff, strip int) diffIndex {
+	linesPerFile := make(diffIndex)
 	for _, file := range fileDiffs {
-		path := normalizeDiffPath(file.PathNew, 1)
+		path := normalizeDiffPath(file.PathNew, strip)
 		lines, ok := linesPerFile[path]
 		if !ok {
 			lines = make(map[int]*diff.Line)
@@ -74,6 +262,18 @@ func createDiffMappingDataStructures(fileDiffs []*diff.FileDiff) {
 		linesPerFile[path] = lines
 
 	}
+	return linesPerFile
+}
+
+// hunkEndLine clamps the end line of a violation to the hunk of its start
+// line. New lines of a hunk are contiguous in lines while hunks are separated
+// by lines which are not in the diff.
+func hunkEndLine(lines map[int]*diff.Line, start, end int) int {
+	last := start
+	for l := start + 1; l <= end && lines[l] != nil; l++ {
+		last = l
+	}
+	return last
 }
 
 func normalizeDiffPath(diffpath string, strip int) string {
@@ -91,23 +291,65 @@ func splitPathList(path string) []string {
 	return strings.Split(filepath.ToSlash(path), "/")
 }
 
-func filterCheckStyleErrors(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat) []*checkstylexml.CheckStyleErrorFormat {
+// outOfDiff returns violations which are not in inDiff.
+func outOfDiff(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, inDiff []*checkstylexml.CheckStyleErrorFormat) []*checkstylexml.CheckStyleErrorFormat {
+	in := make(map[*checkstylexml.CheckStyleErrorFormat]bool, len(inDiff))
+	for _, res := range inDiff {
+		in[res] = true
+	}
+	out := make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	for _, results := range checkStyleResults {
+		for _, res := range results {
+			if !in[res] {
+				out = append(out, res)
+			}
+		}
+	}
+	return out
+}
+
+// filterBySeverity drops violations below minSeverity.
+func filterBySeverity(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, minSeverity comment.Severity) map[string][]*checkstylexml.CheckStyleErrorFormat {
+	if minSeverity == comment.SeverityUnknown {
+		return checkStyleResults
+	}
+	filtered := make(map[string][]*checkstylexml.CheckStyleErrorFormat, len(checkStyleResults))
+	for fileName, checkStyleResult := range checkStyleResults {
+		for _, checkStyleErr := range checkStyleResult {
+			if comment.ParseSeverity(checkStyleErr.Severity).AtLeast(minSeverity) {
+				filtered[fileName] = append(filtered[fileName], checkStyleErr)
+			}
+		}
+	}
+	return filtered
+}
+
+// filterCheckStyleErrors returns violations which should be reported in a
+// given mode. Reported violations on lines of the diff are returned as
+// filterErrors and the others, which cannot be commented inline, as
+// outsideErrors.
+func filterCheckStyleErrors(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, linesPerFile diffIndex, mode FilterMode) (filterErrors, outsideErrors []*checkstylexml.CheckStyleErrorFormat) {
 	cwd, _ := os.Getwd()
-	var filterErrors = make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	filterErrors = make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	outsideErrors = make([]*checkstylexml.CheckStyleErrorFormat, 0)
+	dropped := 0
 	for fileName, checkStyleResult := range checkStyleResults {
-		fmt.Printf("Before it was normalized: %s\n", fileName)
-		pathFileName := github.NormalizePath(fileName, cwd, "")
-		fmt.Printf("Filter file name: %s\n", pathFileName)
-		_, ok := linesPerFile[pathFileName]
-		if ok {
-			for _, checkStyleErr := range checkStyleResult {
-				newLine := checkStyleErr.Line
-				_, isFine := linesPerFile[pathFileName][newLine]
-				if isFine {
-					filterErrors = append(filterErrors, checkStyleErr)
-				}
+		fmt.Fprintf(os.Stderr, "Before it was normalized: %s\n", fileName)
+		pathFileName := pathutil.NormalizePath(fileName, cwd, "")
+		fmt.Fprintf(os.Stderr, "Filter file name: %s\n", pathFileName)
+		lines, inFile := linesPerFile[pathFileName]
+		for _, checkStyleErr := range checkStyleResult {
+			line := lines[checkStyleErr.Line]
+			switch {
+			case !mode.shouldReport(line, inFile):
+				dropped++
+			case line != nil:
+				filterErrors = append(filterErrors, checkStyleErr)
+			default:
+				outsideErrors = append(outsideErrors, checkStyleErr)
 			}
 		}
 	}
-	return filterErrors
+	fmt.Fprintf(os.Stderr, "Errors dropped by filter mode %s: %d\n", mode, dropped)
+	return filterErrors, outsideErrors
 }
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"checkstyle-review/checkstylexml"
+	"checkstyle-review/glob"
+	"checkstyle-review/pathutil"
+	"os"
+	"path/filepath"
+)
+
+// Select returns violations which are not ignored by the rules, paths,
+// minimum severity, suppressions and baseline of opts, regardless of the diff.
+func Select(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, opts Options) map[string][]*checkstylexml.CheckStyleErrorFormat {
+	results, _ := selectResults(checkStyleResults, opts)
+	return results
+}
+
+// selectResults is Select which also returns the number of violations dropped
+// by each suppression and by the baseline.
+func selectResults(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, opts Options) (map[string][]*checkstylexml.CheckStyleErrorFormat, map[string]int) {
+	results := filterBySeverity(filterByRuleAndPath(checkStyleResults, opts), opts.MinSeverity)
+	results, suppressed := suppress(results, opts.Suppressions, opts.rootDir())
+	results, n := filterByBaseline(results, opts.Baseline)
+	if n > 0 {
+		suppressed[baselineSuppression] += n
+	}
+	return results, suppressed
+}
+
+// baselineSuppression is the name of violations suppressed by the baseline in
+// the summary.
+const baselineSuppression = "baseline"
+
+// filterByBaseline drops violations whose baseline key is in baseline and
+// returns the number of dropped ones.
+func filterByBaseline(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, baseline map[string]bool) (map[string][]*checkstylexml.CheckStyleErrorFormat, int) {
+	if len(baseline) == 0 {
+		return checkStyleResults, 0
+	}
+	dropped := 0
+	filtered := make(map[string][]*checkstylexml.CheckStyleErrorFormat, len(checkStyleResults))
+	for fileName, checkStyleResult := range checkStyleResults {
+		for _, checkStyleErr := range checkStyleResult {
+			if baseline[checkStyleErr.BaselineKey] {
+				dropped++
+				continue
+			}
+			filtered[fileName] = append(filtered[fileName], checkStyleErr)
+		}
+	}
+	return filtered, dropped
+}
+
+// filterByRuleAndPath drops violations of Options.IgnoreRules and of paths
+// which are not included by Options.IncludePaths and Options.ExcludePaths.
+func filterByRuleAndPath(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, opts Options) map[string][]*checkstylexml.CheckStyleErrorFormat {
+	if len(opts.IgnoreRules) == 0 && len(opts.IncludePaths) == 0 && len(opts.ExcludePaths) == 0 {
+		return checkStyleResults
+	}
+	ignored := make(map[string]bool, len(opts.IgnoreRules))
+	for _, rule := range opts.IgnoreRules {
+		ignored[rule] = true
+	}
+	root := opts.rootDir()
+	filtered := make(map[string][]*checkstylexml.CheckStyleErrorFormat, len(checkStyleResults))
+	for fileName, checkStyleResult := range checkStyleResults {
+		if !includePath(rootRelPath(fileName, root), opts.IncludePaths, opts.ExcludePaths) {
+			continue
+		}
+		for _, checkStyleErr := range checkStyleResult {
+			if !ignored[checkStyleErr.Source] {
+				filtered[fileName] = append(filtered[fileName], checkStyleErr)
+			}
+		}
+	}
+	return filtered
+}
+
+func (o *Options) rootDir() string {
+	if o.RootDir != "" {
+		return o.RootDir
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// rootRelPath returns the path of a file relative to root. Relative paths of
+// reports are relative to the working directory.
+func rootRelPath(fileName, root string) string {
+	if !filepath.IsAbs(fileName) {
+		cwd, _ := os.Getwd()
+		fileName = filepath.Join(cwd, fileName)
+	}
+	return pathutil.NormalizePath(fileName, root, "")
+}
+
+func includePath(path string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if glob.Match(pattern, path) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if glob.Match(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
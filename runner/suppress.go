@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"checkstyle-review/checkstylexml"
+	"regexp"
+	"strings"
+)
+
+// Suppression represents a rule which drops matching violations before they
+// are filtered by the diff. A violation matches when it matches every
+// condition which is set.
+type Suppression struct {
+	// Name identifies the suppression in the summary. String is used if empty.
+	Name string
+	// Source matches the source of a violation exactly.
+	Source string
+	// SourcePattern matches the source of a violation.
+	SourcePattern *regexp.Regexp
+	// MessagePattern matches the message of a violation.
+	MessagePattern *regexp.Regexp
+	// Paths are glob patterns of paths, one of which must match.
+	Paths []string
+}
+
+// String returns the name of the suppression, or its conditions if Name is
+// not set.
+func (s *Suppression) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	var conds []string
+	if s.Source != "" {
+		conds = append(conds, "source="+s.Source)
+	}
+	if s.SourcePattern != nil {
+		conds = append(conds, "source~"+s.SourcePattern.String())
+	}
+	if s.MessagePattern != nil {
+		conds = append(conds, "message~"+s.MessagePattern.String())
+	}
+	if len(s.Paths) > 0 {
+		conds = append(conds, "paths="+strings.Join(s.Paths, ","))
+	}
+	return strings.Join(conds, " ")
+}
+
+// matches reports whether the violation at the normalized path matches the
+// suppression. A suppression without conditions matches nothing.
+func (s *Suppression) matches(path string, e *checkstylexml.CheckStyleErrorFormat) bool {
+	if s.Source == "" && s.SourcePattern == nil && s.MessagePattern == nil && len(s.Paths) == 0 {
+		return false
+	}
+	if s.Source != "" && s.Source != e.Source {
+		return false
+	}
+	if s.SourcePattern != nil && !s.SourcePattern.MatchString(e.Source) {
+		return false
+	}
+	if s.MessagePattern != nil && !s.MessagePattern.MatchString(e.Message) {
+		return false
+	}
+	if len(s.Paths) > 0 && !includePath(path, s.Paths, nil) {
+		return false
+	}
+	return true
+}
+
+// suppress drops violations matching one of suppressions, whose paths are
+// relative to root. It returns the kept violations and the number of dropped
+// ones per suppression. A violation is counted for the first suppression it
+// matches.
+func suppress(checkStyleResults map[string][]*checkstylexml.CheckStyleErrorFormat, suppressions []*Suppression, root string) (map[string][]*checkstylexml.CheckStyleErrorFormat, map[string]int) {
+	suppressed := make(map[string]int)
+	if len(suppressions) == 0 {
+		return checkStyleResults, suppressed
+	}
+	kept := make(map[string][]*checkstylexml.CheckStyleErrorFormat, len(checkStyleResults))
+	for fileName, checkStyleResult := range checkStyleResults {
+		path := rootRelPath(fileName, root)
+	results:
+		for _, checkStyleErr := range checkStyleResult {
+			for _, s := range suppressions {
+				if s.matches(path, checkStyleErr) {
+					suppressed[s.String()]++
+					continue results
+				}
+			}
+			kept[fileName] = append(kept[fileName], checkStyleErr)
+		}
+	}
+	return kept, suppressed
+}
@@ -0,0 +1,225 @@
+// Package sarif provides a parser of SARIF 2.1.0 reports.
+//
+// References:
+//   - https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+package sarif
+
+import (
+	"checkstyle-review/checkstylexml"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// SARIF is a parser of SARIF report.
+type SARIF struct{}
+
+// ParseErrors parses SARIF report and returns its results as violations.
+func (*SARIF) ParseErrors(r io.Reader) ([]*checkstylexml.CheckStyleErrorFormat, error) {
+	var log Log
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, err
+	}
+	var errs []*checkstylexml.CheckStyleErrorFormat
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 || result.Locations[0].PhysicalLocation.ArtifactLocation.URI == "" {
+				// A violation without a file location (e.g. only a logical
+				// location) cannot be mapped to a file.
+				continue
+			}
+			loc := result.Locations[0].PhysicalLocation
+			region := loc.Region
+			errs = append(errs, &checkstylexml.CheckStyleErrorFormat{
+				File:      uriToPath(loc.ArtifactLocation.URI),
+				Column:    region.StartColumn,
+				Line:      region.StartLine,
+				EndLine:   region.EndLine,
+				EndColumn: region.EndColumn,
+				Message:   result.Message.Text,
+				Severity:  severity(level(result, run)),
+				Source:    ruleID(result, run),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// Log represents the root object of SARIF report.
+type Log struct {
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run represents a single invocation of an analysis tool.
+type Run struct {
+	Tool struct {
+		Driver     ToolComponent    `json:"driver"`
+		Extensions []*ToolComponent `json:"extensions"`
+	} `json:"tool"`
+	Results []*Result `json:"results"`
+}
+
+// ToolComponent represents the driver or an extension (e.g. a query pack) of
+// a tool. CodeQL, for example, reports its rules in extensions.
+type ToolComponent struct {
+	Name  string                 `json:"name"`
+	Rules []*ReportingDescriptor `json:"rules"`
+}
+
+// ReportingDescriptor represents a rule of a tool.
+type ReportingDescriptor struct {
+	ID                   string `json:"id"`
+	DefaultConfiguration *struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+// Result represents a single violation.
+type Result struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      *struct {
+		ID            string `json:"id"`
+		Index         *int   `json:"index"`
+		ToolComponent *struct {
+			Name  string `json:"name"`
+			Index *int   `json:"index"`
+		} `json:"toolComponent"`
+	} `json:"rule"`
+	Level   string `json:"level"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+	} `json:"locations"`
+}
+
+// PhysicalLocation represents a location in a file.
+type PhysicalLocation struct {
+	ArtifactLocation struct {
+		URI string `json:"uri"`
+	} `json:"artifactLocation"`
+	Region Region `json:"region"`
+}
+
+// Region represents a range in a file.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// ruleID returns the rule id of a result. The id may be given directly,
+// through a rule reference or only through an index into the rules of the tool.
+func ruleID(result *Result, run *Run) string {
+	if result.RuleID != "" {
+		return result.RuleID
+	}
+	if result.Rule != nil && result.Rule.ID != "" {
+		return result.Rule.ID
+	}
+	if rule := rule(result, run); rule != nil {
+		return rule.ID
+	}
+	return ""
+}
+
+// rule returns the rule of the tool a result refers to by index, or by id if
+// there is no index or the index misses. It returns nil if the rule is not
+// found.
+func rule(result *Result, run *Run) *ReportingDescriptor {
+	comp := component(result, run)
+	if comp == nil {
+		return nil
+	}
+	id := result.RuleID
+	if id == "" && result.Rule != nil {
+		id = result.Rule.ID
+	}
+	index := result.RuleIndex
+	if result.Rule != nil && result.Rule.Index != nil {
+		index = result.Rule.Index
+	}
+	if index != nil && *index >= 0 && *index < len(comp.Rules) {
+		if r := comp.Rules[*index]; id == "" || r.ID == id {
+			return r
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	for _, r := range comp.Rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// component returns the tool component that defines the rule of a result: an
+// extension when the rule reference names one (SARIF §3.52.7), the driver
+// otherwise.
+func component(result *Result, run *Run) *ToolComponent {
+	if result.Rule == nil || result.Rule.ToolComponent == nil {
+		return &run.Tool.Driver
+	}
+	tc := result.Rule.ToolComponent
+	if tc.Index != nil {
+		if *tc.Index >= 0 && *tc.Index < len(run.Tool.Extensions) {
+			return run.Tool.Extensions[*tc.Index]
+		}
+		return nil
+	}
+	if tc.Name != "" && tc.Name != run.Tool.Driver.Name {
+		for _, ext := range run.Tool.Extensions {
+			if ext.Name == tc.Name {
+				return ext
+			}
+		}
+		return nil
+	}
+	return &run.Tool.Driver
+}
+
+// level returns the level of a result. An absent level falls back to the
+// default configuration of its rule and then to "warning" (SARIF §3.27.10).
+func level(result *Result, run *Run) string {
+	if result.Level != "" {
+		return result.Level
+	}
+	if rule := rule(result, run); rule != nil && rule.DefaultConfiguration != nil && rule.DefaultConfiguration.Level != "" {
+		return rule.DefaultConfiguration.Level
+	}
+	return "warning"
+}
+
+// severity maps SARIF level to checkstyle severity.
+func severity(level string) string {
+	switch level {
+	case "error":
+		return "error"
+	case "note", "none":
+		return "info"
+	default:
+		return "warning"
+	}
+}
+
+// uriToPath converts an artifact URI into a file path.
+func uriToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file:") {
+		if p, err := url.PathUnescape(uri); err == nil {
+			return p
+		}
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return u.Path
+}
@@ -0,0 +1,56 @@
+package sarif
+
+import (
+	"checkstyle-review/checkstylexml"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	f, err := os.Open("testdata/codeql.sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := (&SARIF{}).ParseErrors(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*checkstylexml.CheckStyleErrorFormat{
+		{
+			// level is absent, so the default level of the rule is used.
+			File: "src/main/java/Dao.java", Line: 10, Column: 5, EndLine: 12, EndColumn: 20,
+			Message: "Query built from user input.", Severity: "error", Source: "java/sql-injection",
+		},
+		{
+			// level of the result wins over the default level of the rule.
+			File: "/work/src/main/java/My App.java", Line: 3,
+			Message: "Unused variable x.", Severity: "warning", Source: "java/unused-variable",
+		},
+		{
+			// the rule index misses, so the rule is looked up by id.
+			File: "src/A.java", Line: 7,
+			Message: "Unused variable y.", Severity: "info", Source: "java/unused-variable",
+		},
+		{
+			File: "src/A.java", Line: 8,
+			Message: "No default level.", Severity: "warning", Source: "java/no-default",
+		},
+		// results without a file location are skipped.
+		{
+			// the rule is defined by the driver.
+			File: "src/B.java", Line: 2,
+			Message: "Rule of the driver.", Severity: "error", Source: "L001",
+		},
+	}
+	if len(got) != len(want) {
+		t.Errorf("ParseErrors() returned %d violations, want %d", len(got), len(want))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("got[%d] = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
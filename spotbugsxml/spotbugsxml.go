@@ -0,0 +1,142 @@
+package spotbugsxml
+
+import (
+	"checkstyle-review/checkstylexml"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpotBugsXML is a parser of SpotBugs XML report.
+type SpotBugsXML struct{}
+
+func (*SpotBugsXML) Parse(r io.Reader) (*BugCollection, error) {
+	var result = new(BugCollection)
+	err := xml.NewDecoder(r).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseErrors parses SpotBugs XML report and returns its bugs as violations.
+func (s *SpotBugsXML) ParseErrors(r io.Reader) ([]*checkstylexml.CheckStyleErrorFormat, error) {
+	result, err := s.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	var errs []*checkstylexml.CheckStyleErrorFormat
+	for _, bug := range result.BugInstances {
+		sl := bug.primarySourceLine()
+		if sl == nil || sl.SourcePath == "" {
+			// A bug without a source line cannot be mapped to a file.
+			continue
+		}
+		errs = append(errs, &checkstylexml.CheckStyleErrorFormat{
+			File:     result.Project.resolve(sl.SourcePath),
+			Line:     sl.Start,
+			EndLine:  sl.End,
+			Message:  bug.message(),
+			Severity: severity(bug.Priority),
+			Source:   bug.Type,
+		})
+	}
+	return errs, nil
+}
+
+// BugCollection represents SpotBugs XML result.
+// <?xml version="1.0" encoding="UTF-8"?><BugCollection version="4.7.3"><Project ...></Project><BugInstance ...></BugInstance>...</BugCollection>
+//
+// References:
+//   - https://spotbugs.readthedocs.io/en/latest/
+//   - https://github.com/spotbugs/spotbugs/blob/master/spotbugs/etc/bugcollection.xsd
+type BugCollection struct {
+	XMLName      xml.Name       `xml:"BugCollection"`
+	Version      string         `xml:"version,attr"`
+	Project      Project        `xml:"Project"`
+	BugInstances []*BugInstance `xml:"BugInstance"`
+}
+
+// Project represents <Project><SrcDir>dir</SrcDir>...</Project>
+type Project struct {
+	SrcDirs []string `xml:"SrcDir"`
+}
+
+// BugInstance represents <BugInstance type="t" priority="1" category="c"><LongMessage>msg</LongMessage><SourceLine ... />...</BugInstance>
+type BugInstance struct {
+	Type         string        `xml:"type,attr"`
+	Priority     int           `xml:"priority,attr"`
+	Category     string        `xml:"category,attr"`
+	ShortMessage string        `xml:"ShortMessage"`
+	LongMessage  string        `xml:"LongMessage"`
+	Class        BugClass      `xml:"Class"`
+	SourceLines  []*SourceLine `xml:"SourceLine"`
+}
+
+// BugClass represents <Class classname="c"><SourceLine ... /></Class>
+type BugClass struct {
+	SourceLine *SourceLine `xml:"SourceLine"`
+}
+
+// SourceLine represents <SourceLine start="1" end="2" sourcepath="com/example/Foo.java" primary="true" />
+type SourceLine struct {
+	Start      int    `xml:"start,attr"`
+	End        int    `xml:"end,attr"`
+	SourceFile string `xml:"sourcefile,attr"`
+	SourcePath string `xml:"sourcepath,attr"`
+	Primary    bool   `xml:"primary,attr"`
+}
+
+// primarySourceLine returns the source line the bug is reported on. It falls
+// back to the source line of the class when the bug has no own source line.
+func (b *BugInstance) primarySourceLine() *SourceLine {
+	for _, sl := range b.SourceLines {
+		if sl.Primary {
+			return sl
+		}
+	}
+	if len(b.SourceLines) > 0 {
+		return b.SourceLines[0]
+	}
+	return b.Class.SourceLine
+}
+
+// message returns the most descriptive message available. Messages are only
+// present if the report has been generated with messages.
+func (b *BugInstance) message() string {
+	if m := strings.TrimSpace(b.LongMessage); m != "" {
+		return m
+	}
+	if m := strings.TrimSpace(b.ShortMessage); m != "" {
+		return m
+	}
+	return b.Type
+}
+
+// resolve returns the path of a source file. sourcepath is relative to one of
+// the source directories of the project, so the first one containing the file
+// wins.
+func (p *Project) resolve(sourcePath string) string {
+	for _, dir := range p.SrcDirs {
+		path := filepath.Join(dir, sourcePath)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return sourcePath
+}
+
+// severity maps SpotBugs priority (1 is high, 2 is normal and 3 is low) to
+// checkstyle severity.
+func severity(priority int) string {
+	switch priority {
+	case 1:
+		return "error"
+	case 2:
+		return "warning"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,45 @@
+package spotbugsxml
+
+import (
+	"checkstyle-review/checkstylexml"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	f, err := os.Open("testdata/spotbugs.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := (&SpotBugsXML{}).ParseErrors(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*checkstylexml.CheckStyleErrorFormat{
+		{
+			// the primary source line wins and the file is found in the
+			// second source directory.
+			File: "testdata/src/com/example/Foo.java", Line: 18, EndLine: 22,
+			Message:  "Possible null pointer dereference of s in com.example.Foo.run()",
+			Severity: "error", Source: "NP_NULL_ON_SOME_PATH",
+		},
+		{
+			// the source line of the class is used, and the path is kept
+			// as is since no source directory contains it.
+			File: "com/example/Bar.java", Line: 3, EndLine: 30,
+			Message:  "Non-transient non-serializable instance field",
+			Severity: "warning", Source: "SE_BAD_FIELD",
+		},
+	}
+	if len(got) != len(want) {
+		t.Errorf("ParseErrors() returned %d violations, want %d", len(got), len(want))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("got[%d] = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
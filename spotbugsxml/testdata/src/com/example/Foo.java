@@ -0,0 +1 @@
+package com.example;